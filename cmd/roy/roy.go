@@ -47,6 +47,7 @@ Usage:
    roy inspect -help
    roy sets -help
    roy compare -help
+   roy dupes -help
 `
 
 var inspectUsage = `
@@ -195,6 +196,9 @@ var (
 	// COMPARE
 	comparef    = flag.NewFlagSet("compare", flag.ExitOnError)
 	compareJoin = comparef.Int("join", 0, "control which field(s) are used to link results files. Default is 0 (full file path). Other options are 1 (filename), 2, (filename + size), 3 (filename + modified), 4 (filename + hash), 5 (hash)")
+
+	// DUPES
+	dupesf = flag.NewFlagSet("dupes", flag.ExitOnError)
 )
 
 func savereps() error {
@@ -634,6 +638,11 @@ func main() {
 		if err == nil {
 			err = reader.Compare(os.Stdout, *compareJoin, comparef.Args()...)
 		}
+	case "dupes":
+		err = dupesf.Parse(os.Args[2:])
+		if err == nil {
+			err = reader.Dupes(os.Stdout, dupesf.Args()...)
+		}
 	default:
 		log.Fatal(usage)
 	}
@@ -0,0 +1,527 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/richardlehane/siegfried"
+	"github.com/richardlehane/siegfried/internal/checksum"
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/decompress"
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+// With -bag, the path scanned is a BagIt bag (RFC 8493): a directory with a bagit.txt file or, with -z, a zip or tar file
+// that contains one (at the top level or in a single top-level directory). The bag is validated before its payload is
+// identified: bagit.txt and bag-info.txt must be well formed, every payload file must be listed in every payload manifest
+// with a matching checksum, the payload must match the Payload-Oxum (if given), and tag files must match the tag manifests.
+// Only the payload (the files in data/) is identified, and paths are reported relative to the bag.
+//
+// As with -verify, results have a fixity field, and files in the payload manifests that aren't in the bag are reported as
+// "missing-on-disk" at the end of the results. The bag's validity, and any problems, are reported in the results header
+// (YAML and JSON output) and logged. Validation reads the payload in full before it is identified.
+
+// bg is nil unless the -bag flag is given
+var bg *bag
+
+const bagPayload = "data/"
+
+type bag struct {
+	path     string
+	sf       *siegfried.Siegfried // reads serialized bags
+	arc      config.Archive       // the format of a serialized bag, config.None for a directory
+	root     string               // the bag's top-level directory within a serialized bag, with a trailing slash, if any
+	prefix   string               // the prefix of the paths of the files in the bag, as scanned
+	version  string               // BagIt-Version
+	tags     map[string][]byte    // bagit.txt, bag-info.txt and the manifests
+	payload  []bagManifest
+	tagged   []bagManifest     // tag manifests
+	fixity   map[string]string // the fixity of each payload file
+	missing  []string          // files listed in the payload manifests that aren't in the bag
+	counts   map[string]int
+	problems []string
+}
+
+type bagManifest struct {
+	name string
+	typ  checksum.HashTyp
+	sums map[string][]byte // checksums by path, relative to the bag
+}
+
+// bagFile is a file in a bag's directory or archive
+type bagFile struct {
+	name string // the path within the directory or archive, with forward slashes
+	path string // the path as scanned
+	sz   int64
+	mod  time.Time
+	open func() (io.Reader, error)
+}
+
+// newBag reads and validates a bag
+func newBag(p string, sf *siegfried.Siegfried) (*bag, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	b := &bag{
+		path:   p,
+		sf:     sf,
+		tags:   make(map[string][]byte),
+		fixity: make(map[string]string),
+		counts: make(map[string]int),
+	}
+	if info.IsDir() {
+		b.prefix = filepath.Clean(p) + string(filepath.Separator)
+	} else {
+		if !*archive {
+			return nil, fmt.Errorf("%s is not a directory; use -z to scan a serialized bag", p)
+		}
+		if b.arc, err = b.format(); err != nil {
+			return nil, err
+		}
+	}
+	// read the tag files at the top of the bag, or of a single top-level directory in a serialized bag
+	err = b.walk(true, func(f bagFile) error {
+		if strings.Count(f.name, "/") > 1 || !isTagFile(path.Base(f.name)) {
+			return nil
+		}
+		r, err := f.open()
+		if err != nil {
+			return err
+		}
+		b.tags[f.name], err = io.ReadAll(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := b.tags["bagit.txt"]; !ok {
+		for name := range b.tags {
+			if path.Base(name) == "bagit.txt" {
+				if b.root != "" {
+					return nil, fmt.Errorf("%s contains more than one bag", p)
+				}
+				b.root = path.Dir(name) + "/"
+			}
+		}
+		if b.root == "" {
+			return nil, fmt.Errorf("%s is not a bag, it has no bagit.txt", p)
+		}
+		tags := make(map[string][]byte)
+		for name, byts := range b.tags {
+			if strings.HasPrefix(name, b.root) {
+				tags[strings.TrimPrefix(name, b.root)] = byts
+			}
+		}
+		b.tags = tags
+	}
+	if b.arc != config.None {
+		b.prefix = decompress.Arcpath(p, filepath.FromSlash(b.root))
+	}
+	return b, b.validate()
+}
+
+func isTagFile(name string) bool {
+	return name == "bagit.txt" || name == "bag-info.txt" ||
+		(strings.HasSuffix(name, ".txt") && (strings.HasPrefix(name, "manifest-") || strings.HasPrefix(name, "tagmanifest-")))
+}
+
+// format identifies a serialized bag, which must be a zip or tar file
+func (b *bag) format() (config.Archive, error) {
+	f, err := os.Open(b.path)
+	if err != nil {
+		return config.None, err
+	}
+	defer f.Close()
+	buf, err := b.sf.Buffer(f)
+	if err != nil {
+		return config.None, err
+	}
+	defer b.sf.Put(buf)
+	ids, err := b.sf.IdentifyBuffer(buf, nil, b.path, "")
+	if err != nil {
+		return config.None, err
+	}
+	if arc := decompress.IsArc(ids); arc == config.Zip || arc == config.Tar {
+		return arc, nil
+	}
+	return config.None, fmt.Errorf("%s is not a directory, zip or tar file", b.path)
+}
+
+// walk calls fn for each file in a bag's directory or archive. If top is true, sub-directories of a directory aren't walked.
+func (b *bag) walk(top bool, fn func(bagFile) error) error {
+	if b.arc == config.None {
+		root := filepath.Clean(b.path)
+		return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if top && p != root {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			var f *os.File
+			defer func() {
+				if f != nil {
+					f.Close()
+				}
+			}()
+			return fn(bagFile{relPath(root, p), p, info.Size(), info.ModTime(), func() (io.Reader, error) {
+				var oerr error
+				f, oerr = os.Open(p)
+				return f, oerr
+			}})
+		})
+	}
+	f, err := os.Open(b.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	buf, err := b.sf.Buffer(f)
+	if err != nil {
+		return err
+	}
+	defer b.sf.Put(buf)
+	d, err := decompress.New(b.arc, buf, b.path)
+	if err != nil {
+		return err
+	}
+	base := decompress.Arcpath(b.path, "")
+	for err = d.Next(); err == nil || isEntryErr(err); err = d.Next() {
+		bf := bagFile{filepath.ToSlash(strings.TrimPrefix(d.Path(), base)), d.Path(), d.Size(), d.Mod(), nil}
+		if strings.HasSuffix(bf.name, "/") {
+			continue
+		}
+		if err != nil {
+			eerr := err
+			bf.open = func() (io.Reader, error) { return nil, eerr }
+		} else {
+			r := d.Reader()
+			bf.open = func() (io.Reader, error) { return r, nil }
+		}
+		if ferr := fn(bf); ferr != nil {
+			return ferr
+		}
+	}
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+func (b *bag) problem(format string, a ...interface{}) {
+	b.problems = append(b.problems, fmt.Sprintf(format, a...))
+}
+
+// validate checks the tag files, then reads the bag's files to check them against the manifests
+func (b *bag) validate() error {
+	if tags, err := parseTags("bagit.txt", b.tags["bagit.txt"]); err != nil {
+		b.problem("%v", err)
+	} else {
+		b.version = tagValue(tags, "BagIt-Version")
+		if b.version == "" {
+			b.problem("bagit.txt has no BagIt-Version")
+		}
+		switch enc := tagValue(tags, "Tag-File-Character-Encoding"); {
+		case enc == "":
+			b.problem("bagit.txt has no Tag-File-Character-Encoding")
+		case !strings.EqualFold(enc, "UTF-8"):
+			b.problem("unsupported Tag-File-Character-Encoding %s", enc)
+		}
+	}
+	oxum := [2]int64{-1, -1}
+	if byts, ok := b.tags["bag-info.txt"]; ok {
+		if tags, err := parseTags("bag-info.txt", byts); err != nil {
+			b.problem("%v", err)
+		} else if v := tagValue(tags, "Payload-Oxum"); v != "" {
+			var err error
+			octets, streams, _ := strings.Cut(v, ".")
+			if oxum[0], err = strconv.ParseInt(octets, 10, 64); err == nil {
+				oxum[1], err = strconv.ParseInt(streams, 10, 64)
+			}
+			if err != nil {
+				b.problem("bad Payload-Oxum in bag-info.txt: %s", v)
+				oxum[0] = -1
+			}
+		}
+	}
+	names := make([]string, 0, len(b.tags))
+	for name := range b.tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		alg, payload := strings.TrimPrefix(name, "manifest-"), true
+		if !strings.HasPrefix(name, "manifest-") {
+			alg, payload = strings.TrimPrefix(name, "tagmanifest-"), false
+			if alg == name {
+				continue
+			}
+		}
+		m := bagManifest{name: name, typ: checksum.GetHash(strings.TrimSuffix(alg, ".txt"))}
+		if m.typ < 0 {
+			b.problem("%s uses an unsupported algorithm", name)
+			continue
+		}
+		var err error
+		if m.sums, err = parseManifest(name, b.tags[name], payload); err != nil {
+			b.problem("%v", err)
+			continue
+		}
+		if payload {
+			b.payload = append(b.payload, m)
+		} else {
+			b.tagged = append(b.tagged, m)
+		}
+	}
+	if len(b.payload) == 0 {
+		b.problem("no payload manifest")
+	}
+	// read the files in the bag
+	var octets, streams int64
+	seen := make(map[string]bool)
+	err := b.walk(false, func(f bagFile) error {
+		if !strings.HasPrefix(f.name, b.root) {
+			return nil
+		}
+		rel := strings.TrimPrefix(f.name, b.root)
+		mans := b.payload
+		if strings.HasPrefix(rel, bagPayload) {
+			octets += f.sz
+			streams++
+		} else {
+			mans = nil
+			for _, m := range b.tagged {
+				if _, ok := m.sums[rel]; ok {
+					mans = append(mans, m)
+				}
+			}
+			if len(mans) == 0 {
+				return nil
+			}
+		}
+		seen[rel] = true
+		status := b.check(rel, f, mans)
+		if strings.HasPrefix(rel, bagPayload) {
+			b.fixity[rel] = status
+			b.counts[status]++
+		} else if status != fixityOK {
+			b.problem("tag file %s: %s", rel, status)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, m := range append(b.payload, b.tagged...) {
+		for rel := range m.sums {
+			if seen[rel] {
+				continue
+			}
+			if strings.HasPrefix(rel, bagPayload) {
+				b.missing = append(b.missing, rel)
+			} else {
+				b.problem("tag file %s: %s", rel, fixityMissing)
+			}
+			seen[rel] = true
+		}
+	}
+	sort.Strings(b.missing)
+	b.counts[fixityMissing] = len(b.missing)
+	for _, v := range [][2]string{
+		{fixityMismatch, "payload files don't match their checksums"},
+		{fixityNotInManifest, "payload files aren't in every payload manifest"},
+		{fixityMissing, "files in the payload manifests are missing"},
+		{fixityUnverified, "payload files couldn't be read"},
+	} {
+		if n := b.counts[v[0]]; n > 0 {
+			b.problem("%s: %d", v[1], n)
+		}
+	}
+	if oxum[0] >= 0 && (oxum[0] != octets || oxum[1] != streams) {
+		b.problem("Payload-Oxum %d.%d doesn't match the payload (%d.%d)", oxum[0], oxum[1], octets, streams)
+	}
+	return nil
+}
+
+// check reads a file and returns its fixity against the given manifests
+func (b *bag) check(rel string, f bagFile, mans []bagManifest) string {
+	typs := make(checksum.HashTyps, 0, len(mans))
+	for _, m := range mans {
+		if _, ok := m.sums[rel]; !ok {
+			return fixityNotInManifest
+		}
+		typs = append(typs, m.typ)
+	}
+	if len(typs) == 0 {
+		return fixityNotInManifest
+	}
+	r, err := f.open()
+	if err != nil {
+		return fixityUnverified
+	}
+	h := checksum.MakeHashes(typs)
+	if _, err = io.Copy(h, r); err != nil {
+		return fixityUnverified
+	}
+	for i, sum := range typs.Split(h.Sum(nil)) {
+		if !bytes.Equal(sum, mans[i].sums[rel]) {
+			return fixityMismatch
+		}
+	}
+	return fixityOK
+}
+
+// parseTags reads the labels and values in a tag file such as bagit.txt or bag-info.txt.
+// Values can continue on following lines that are indented.
+func parseTags(name string, byts []byte) ([][2]string, error) {
+	var ret [][2]string
+	for i, line := range strings.Split(strings.TrimPrefix(string(byts), "\ufeff"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		switch {
+		case strings.TrimSpace(line) == "":
+			continue
+		case line[0] == ' ' || line[0] == '\t':
+			if len(ret) == 0 {
+				return nil, fmt.Errorf("%s: bad line %d, expecting a label", name, i+1)
+			}
+			ret[len(ret)-1][1] += " " + strings.TrimSpace(line)
+			continue
+		}
+		label, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(label) == "" {
+			return nil, fmt.Errorf("%s: bad line %d, expecting a label and value", name, i+1)
+		}
+		ret = append(ret, [2]string{strings.TrimSpace(label), strings.TrimSpace(value)})
+	}
+	return ret, nil
+}
+
+func tagValue(tags [][2]string, label string) string {
+	for _, t := range tags {
+		if strings.EqualFold(t[0], label) {
+			return t[1]
+		}
+	}
+	return ""
+}
+
+// parseManifest reads the checksums and paths in a manifest. Paths in payload manifests must be in the payload directory.
+func parseManifest(name string, byts []byte, payload bool) (map[string][]byte, error) {
+	ret := make(map[string][]byte)
+	for i, line := range strings.Split(string(byts), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		idx := strings.IndexAny(line, " \t")
+		if idx < 0 {
+			return nil, fmt.Errorf("%s: bad line %d, expecting a checksum and path", name, i+1)
+		}
+		sum, err := hex.DecodeString(strings.ToLower(line[:idx]))
+		if err != nil {
+			return nil, fmt.Errorf("%s: bad checksum on line %d", name, i+1)
+		}
+		p := bagitPath.Replace(strings.TrimLeft(line[idx:], " \t"))
+		if clean := path.Clean(p); clean != p || path.IsAbs(p) || strings.HasPrefix(p, "../") {
+			return nil, fmt.Errorf("%s: bad path on line %d, %s", name, i+1, p)
+		}
+		if payload != strings.HasPrefix(p, bagPayload) {
+			return nil, fmt.Errorf("%s: %s is not a payload file", name, p)
+		}
+		ret[p] = sum
+	}
+	return ret, nil
+}
+
+// valid reports whether a bag is complete and its files match its manifests
+func (b *bag) valid() bool {
+	return len(b.problems) == 0
+}
+
+// annotations report a bag's validity in the results header
+func (b *bag) annotations() [][2]string {
+	ret := [][2]string{{"bag", b.path}, {"bagversion", b.version}, {"bagvalid", strconv.FormatBool(b.valid())}}
+	if !b.valid() {
+		ret = append(ret, [2]string{"bagproblems", strings.Join(b.problems, "; ")})
+	}
+	return ret
+}
+
+// identify identifies a bag's payload
+func (b *bag) identify(ctxts chan *context, droid bool, flt *filter, gf getFn) error {
+	if b.arc == config.None {
+		root := filepath.Join(b.path, filepath.FromSlash(bagPayload))
+		if _, err := os.Stat(root); err != nil {
+			return nil // reported by validate
+		}
+		return identify(ctxts, root, "", *coe, *nr, droid, flt, gf)
+	}
+	return b.walk(false, func(f bagFile) error {
+		if !strings.HasPrefix(f.name, b.root+bagPayload) {
+			return nil
+		}
+		ctx := gf(f.path, "", f.mod, f.sz)
+		ctx.depth, ctx.parent = 1, b.path
+		ctx.wg.Add(1)
+		ctxts <- ctx
+		r, err := f.open()
+		if err != nil {
+			ctx.res <- results{err: err}
+			return nil
+		}
+		identifyRdr(r, ctx, gf)
+		return nil
+	})
+}
+
+// rel returns the path of a file, or of the contents of an archive in the payload, relative to the bag
+func (b *bag) rel(p string) string {
+	return filepath.ToSlash(strings.TrimPrefix(p, b.prefix))
+}
+
+// writeMissing writes results for the files in the payload manifests that aren't in the bag
+func (b *bag) writeMissing(w writer.Writer) {
+	for _, rel := range b.missing {
+		w.File(rel, 0, "", nil, nil, nil, [2]string{fixityField, fixityMissing})
+	}
+}
+
+// summary logs the bag's validity
+func (b *bag) summary() {
+	if b.valid() {
+		log.Printf("[BAG] %s is valid: %d payload files", b.path, b.counts[fixityOK])
+		return
+	}
+	log.Printf("[BAG] %s is invalid: %s", b.path, strings.Join(b.problems, "; "))
+}
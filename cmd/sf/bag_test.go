@@ -0,0 +1,122 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+// makeBag returns the files in a bag with a payload manifest, tag manifest and Payload-Oxum
+func makeBag(payload map[string]string) map[string]string {
+	files := map[string]string{
+		"bagit.txt":    "BagIt-Version: 1.0\nTag-File-Character-Encoding: UTF-8\n",
+		"bag-info.txt": "Source-Organization: Test\nExternal-Description: a description\n  over two lines\n",
+	}
+	var manifest string
+	var octets int
+	for name, content := range payload {
+		files[name] = content
+		manifest += fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte(content)), name)
+		octets += len(content)
+	}
+	files["bag-info.txt"] += fmt.Sprintf("Payload-Oxum: %d.%d\n", octets, len(payload))
+	files["manifest-sha256.txt"] = manifest
+	var tagmanifest string
+	for _, name := range []string{"bagit.txt", "bag-info.txt", "manifest-sha256.txt"} {
+		tagmanifest += fmt.Sprintf("%x  %s\n", md5.Sum([]byte(files[name])), name)
+	}
+	files["tagmanifest-md5.txt"] = tagmanifest
+	return files
+}
+
+func TestBag(t *testing.T) {
+	if err := setup(); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	files := makeBag(map[string]string{"data/a.txt": "hello", "data/sub/b.txt": "world"})
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := newBag(dir, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.valid() || b.version != "1.0" || len(b.fixity) != 2 {
+		t.Fatalf("expecting a valid bag with two payload files, got %v", b.problems)
+	}
+	if rel := b.rel(filepath.Join(dir, "data", "sub", "b.txt")); rel != "data/sub/b.txt" || b.fixity[rel] != fixityOK {
+		t.Errorf("expecting data/sub/b.txt to be ok, got %s (%s)", rel, b.fixity[rel])
+	}
+	// serialize the bag in a top-level directory
+	zpath := filepath.Join(t.TempDir(), "bag.zip")
+	zf, err := os.Create(zpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(zf)
+	for name, content := range files {
+		w, _ := zw.Create("bag/" + name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	zf.Close()
+	*archive = true
+	config.SetArchiveFilterPermissive(config.ListAllArcTypes())
+	defer func() {
+		*archive = false
+		config.SetArchiveFilterPermissive("")
+	}()
+	b, err = newBag(zpath, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.valid() || b.root != "bag/" || b.fixity["data/a.txt"] != fixityOK {
+		t.Errorf("expecting a valid serialized bag, got %v", b.problems)
+	}
+	// change, remove and add payload files
+	os.WriteFile(filepath.Join(dir, "data", "a.txt"), []byte("jello"), 0644)
+	os.Remove(filepath.Join(dir, "data", "sub", "b.txt"))
+	os.WriteFile(filepath.Join(dir, "data", "c.txt"), []byte("new"), 0644)
+	if b, err = newBag(dir, s); err != nil {
+		t.Fatal(err)
+	}
+	if b.valid() || b.fixity["data/a.txt"] != fixityMismatch || b.fixity["data/c.txt"] != fixityNotInManifest ||
+		len(b.missing) != 1 || b.missing[0] != "data/sub/b.txt" {
+		t.Errorf("expecting an invalid bag, got %v %v %v", b.fixity, b.missing, b.problems)
+	}
+	if !strings.Contains(strings.Join(b.problems, "; "), "Payload-Oxum") {
+		t.Errorf("expecting a Payload-Oxum problem, got %v", b.problems)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tags, err := parseTags("bag-info.txt", []byte("\ufeffContact-Name: Tester\r\nExternal-Description: one\n\ttwo\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tagValue(tags, "contact-name") != "Tester" || tagValue(tags, "External-Description") != "one two" {
+		t.Errorf("bad tags: %v", tags)
+	}
+	if _, err = parseTags("bag-info.txt", []byte("no label\n")); err == nil {
+		t.Error("expecting an error for a line without a label")
+	}
+	if _, err = parseManifest("manifest-md5.txt", []byte("00  data/../../etc/passwd\n"), true); err == nil {
+		t.Error("expecting an error for a path outside the bag")
+	}
+	sums, err := parseManifest("manifest-md5.txt", []byte("0A  data/line%0Abreak\n"), true)
+	if err != nil || len(sums["data/line\nbreak"]) != 1 {
+		t.Errorf("expecting a percent encoded path, got %v (%v)", sums, err)
+	}
+}
@@ -30,7 +30,7 @@ import (
 
 var (
 	// list of flags that can be configured
-	setableFlags = []string{"coe", "csv", "droid", "hash", "json", "log", "multi", "nr", "serve", "sig", "throttle", "yaml", "z", "zs"}
+	setableFlags = []string{"coe", "csv", "droid", "exclude", "hash", "include", "json", "log", "maxdepth", "maxsize", "meta", "minsize", "multi", "nr", "policy", "poll", "serve", "settle", "sig", "skiphidden", "throttle", "timeout", "webmeta", "xattrs", "yaml", "z", "zbytes", "zdepth", "zentries", "zratio", "zs"}
 	// list of flags that control output - these are exclusive of each other
 	outputFlags = []string{"csv", "droid", "json", "yaml"}
 )
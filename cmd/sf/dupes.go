@@ -0,0 +1,54 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/richardlehane/siegfried/pkg/reader"
+)
+
+// With -dupes, files (and the contents of archives, with -z) are grouped by checksum and size as results are written.
+// When the scan completes, a CSV report of the duplicates is written to a file (see reader.Duplicates) and a summary is logged.
+// Checksums are calculated with -hash, or read from the results files given with -replay.
+
+// dup is nil unless the -dupes flag is given
+var dup *duplicates
+
+type duplicates struct {
+	*reader.Duplicates
+	out *os.File
+}
+
+// newDuplicates creates the report file. The hash header can be set later if it isn't known yet (i.e. with -replay).
+func newDuplicates(path, hh string) (*duplicates, error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &duplicates{reader.NewDuplicates(hh), out}, nil
+}
+
+// close writes the report and logs a summary
+func (d *duplicates) close() error {
+	if err := d.Write(d.out); err != nil {
+		d.out.Close()
+		return err
+	}
+	groups, files, redundant := d.Summary()
+	log.Printf("[DUPES] %s: %d groups of duplicates, %d files, %d redundant bytes", d.out.Name(), groups, files, redundant)
+	return d.out.Close()
+}
@@ -0,0 +1,185 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var includef, excludef patterns
+
+func init() {
+	flag.Var(&includef, "include", "only scan files matching a glob pattern (repeatable) e.g. -include '*.pdf' -include 'docs/*'")
+	flag.Var(&excludef, "exclude", "skip files and directories matching a glob pattern (repeatable) e.g. -exclude '*.tmp' -exclude 'cache/'")
+}
+
+// patterns is a repeatable flag for glob patterns. Patterns can also be given as a comma separated list.
+type patterns []string
+
+func (p *patterns) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *patterns) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*p = append(*p, v)
+		}
+	}
+	return nil
+}
+
+// match reports whether a path, relative to the directory a pattern applies to, matches any of the patterns.
+// Patterns without a slash are matched against the file or directory name; others against the whole relative path.
+// Patterns with a trailing slash only match directories.
+func (p patterns) match(rel string, dir bool) bool {
+	name := path.Base(rel)
+	for _, v := range p {
+		if strings.HasSuffix(v, "/") {
+			if !dir {
+				continue
+			}
+			v = strings.TrimSuffix(v, "/")
+		}
+		target := name
+		if strings.Contains(v, "/") {
+			target, v = rel, strings.TrimPrefix(v, "/")
+		}
+		if ok, _ := path.Match(v, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreFile lists exclude patterns for the directory it is in and its sub-directories
+const ignoreFile = ".sfignore"
+
+// filter selects the files that are scanned in directory walks. The root of a walk is always scanned.
+type filter struct {
+	include  patterns
+	exclude  patterns
+	maxDepth int   // 0 for no limit
+	hidden   bool  // skip hidden (dot) files and directories
+	minSize  int64 // 0 for no limit
+	maxSize  int64 // 0 for no limit
+}
+
+// the filter set with the -include, -exclude, -maxdepth, -skiphidden, -minsize and -maxsize flags
+func flagFilter() *filter {
+	return &filter{
+		include:  includef,
+		exclude:  excludef,
+		maxDepth: *maxdepth,
+		hidden:   *skiphidden,
+		minSize:  *minsize,
+		maxSize:  *maxsize,
+	}
+}
+
+// skipError reports why a file or directory was skipped. Skipped files are logged (with -log skip) but not written to results.
+type skipError string
+
+func (se skipError) Error() string {
+	return string(se)
+}
+
+// walker applies a filter during a single directory walk
+type walker struct {
+	*filter
+	root    string
+	ignores map[string]patterns // patterns from .sfignore files, by directory
+}
+
+func (f *filter) walker(root string) *walker {
+	return &walker{filter: f, root: root, ignores: make(map[string]patterns)}
+}
+
+// skip returns a reason if a file or directory should be skipped
+func (w *walker) skip(p string, info os.FileInfo) skipError {
+	dir := info.IsDir()
+	if p == w.root {
+		if dir {
+			w.readIgnore(p)
+		}
+		return ""
+	}
+	rel := relPath(w.root, p)
+	depth := strings.Count(rel, "/") + 1
+	switch {
+	case w.hidden && strings.HasPrefix(info.Name(), "."):
+		return "hidden"
+	case w.exclude.match(rel, dir):
+		return "excluded"
+	case w.ignored(p, dir):
+		return "excluded by " + ignoreFile
+	case w.maxDepth > 0 && (depth > w.maxDepth || (dir && depth >= w.maxDepth)):
+		return "exceeds maximum depth"
+	case dir:
+		w.readIgnore(p)
+		return ""
+	case len(w.include) > 0 && !w.include.match(rel, false):
+		return "not included"
+	case info.Size() < w.minSize:
+		return "smaller than minimum size"
+	case w.maxSize > 0 && info.Size() > w.maxSize:
+		return "larger than maximum size"
+	}
+	return ""
+}
+
+// ignored checks the patterns in .sfignore files in the directories above a path, up to the root
+func (w *walker) ignored(p string, dir bool) bool {
+	for d := filepath.Dir(p); ; d = filepath.Dir(d) {
+		if pats, ok := w.ignores[d]; ok && pats.match(relPath(d, p), dir) {
+			return true
+		}
+		if d == w.root || d == filepath.Dir(d) {
+			return false
+		}
+	}
+}
+
+func (w *walker) readIgnore(dir string) {
+	f, err := os.Open(filepath.Join(dir, ignoreFile))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	var pats patterns
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			pats = append(pats, line)
+		}
+	}
+	if len(pats) > 0 {
+		w.ignores[dir] = pats
+	}
+}
+
+// relPath returns a path relative to a directory, with forward slashes
+func relPath(dir, p string) string {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil {
+		return filepath.ToSlash(p)
+	}
+	return filepath.ToSlash(rel)
+}
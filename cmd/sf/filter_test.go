@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPatterns(t *testing.T) {
+	var p patterns
+	p.Set("*.tmp,cache/")
+	p.Set("docs/*.pdf")
+	for _, v := range []struct {
+		rel    string
+		dir    bool
+		expect bool
+	}{
+		{"a.tmp", false, true},
+		{"sub/a.tmp", false, true},
+		{"cache", true, true},
+		{"sub/cache", true, true},
+		{"cache", false, false},
+		{"docs/a.pdf", false, true},
+		{"sub/docs/a.pdf", false, false},
+		{"a.pdf", false, false},
+	} {
+		if p.match(v.rel, v.dir) != v.expect {
+			t.Errorf("%s: expecting match %v", v.rel, v.expect)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"a.txt":           "aaaa",
+		"b.pdf":           "bbbbbbbb",
+		".hidden":         "hhhh",
+		"sub/c.txt":       "cccc",
+		"sub/c.tmp":       "cccc",
+		"sub/.sfignore":   "# skip temporary files\n*.tmp\n",
+		"sub/deep/d.txt":  "dddd",
+		"cache/e.txt":     "eeee",
+		"other/small.txt": "s",
+	} {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	walk := func(f *filter) string {
+		var ret []string
+		w := f.walker(root)
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if w.skip(path, info) != "" {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.IsDir() {
+				ret = append(ret, relPath(root, path))
+			}
+			return nil
+		})
+		sort.Strings(ret)
+		return strings.Join(ret, " ")
+	}
+	for _, v := range []struct {
+		f      *filter
+		expect string
+	}{
+		{&filter{}, ".hidden a.txt b.pdf cache/e.txt other/small.txt sub/.sfignore sub/c.txt sub/deep/d.txt"},
+		{&filter{hidden: true, exclude: patterns{"cache/"}}, "a.txt b.pdf other/small.txt sub/c.txt sub/deep/d.txt"},
+		{&filter{include: patterns{"*.txt"}, maxDepth: 2}, "a.txt cache/e.txt other/small.txt sub/c.txt"},
+		{&filter{minSize: 2, maxSize: 4}, ".hidden a.txt cache/e.txt sub/c.txt sub/deep/d.txt"},
+	} {
+		if got := walk(v.f); got != v.expect {
+			t.Errorf("%+v: expecting %q, got %q", v.f, v.expect, got)
+		}
+	}
+}
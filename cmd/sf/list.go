@@ -0,0 +1,143 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+// With -f, lists of files are scanned: one file per line or, with -f0, separated by NUL characters (e.g. the output of
+// find -print0), so that file names can contain newlines. A list can be read from stdin with -.
+// Entries can give a MIME type and a display name after the file name, separated by tabs:
+//
+//	path/to/file<TAB>application/pdf<TAB>report.pdf
+//
+// An entry that names an existing file in full is read as a file name, so file names can still contain tabs.
+// The MIME type is used as a hint by the MIME matcher. With a display name, the file is identified and reported
+// under that name (as with -name for stdin). Empty entries are ignored. With -replay, each entry is a results file.
+
+// listEntry is an entry in a list of files
+type listEntry struct {
+	path, mime, name string
+}
+
+// parseListEntry reads an entry's MIME type and display name columns, unless the whole entry is the path of a file
+func parseListEntry(s string) listEntry {
+	if !strings.Contains(s, "\t") {
+		return listEntry{path: s}
+	}
+	if _, err := os.Lstat(s); err == nil {
+		return listEntry{path: s}
+	}
+	fields := strings.SplitN(s, "\t", 3)
+	e := listEntry{path: fields[0]}
+	if len(fields) > 1 {
+		e.mime = strings.TrimSpace(fields[1])
+	}
+	if len(fields) > 2 {
+		e.name = strings.TrimSpace(fields[2])
+	}
+	return e
+}
+
+// scanNul is a bufio.SplitFunc for NUL separated lists
+func scanNul(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// readList calls fn for each entry in a list of files
+func readList(r io.Reader, nul bool, fn func(string) error) error {
+	scanner := bufio.NewScanner(r)
+	if nul {
+		scanner.Split(scanNul)
+	}
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			continue
+		}
+		if err := fn(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// scanList identifies the files (or, with -replay, replays the results files) in a list
+func scanList(path string, ctxts chan *context, w writer.Writer, d bool, flt *filter, gf getFn) error {
+	f, err := openFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return readList(f, *list0, func(s string) error {
+		if *replay {
+			return replayFile(s, ctxts, w)
+		}
+		e := parseListEntry(s)
+		if err := identifyEntry(ctxts, e, d, flt, gf); err != nil {
+			printFile(ctxts, gf(e.path, "", time.Time{}, 0), fmt.Errorf("failed to identify %s: %v", e.path, err))
+		}
+		return nil
+	})
+}
+
+func identifyEntry(ctxts chan *context, e listEntry, d bool, flt *filter, gf getFn) error {
+	if e.name != "" {
+		f, err := os.Open(e.path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return modeError(info.Mode())
+		}
+		ctx := gf(e.name, e.mime, info.ModTime(), info.Size())
+		ctx.wg.Add(1)
+		ctxts <- ctx
+		identifyRdr(f, ctx, gf)
+		return nil
+	}
+	if e.mime != "" {
+		next := gf
+		gf = func(p, mime string, mod time.Time, sz int64) *context {
+			if p == e.path && mime == "" {
+				mime = e.mime
+			}
+			return next(p, mime, mod, sz)
+		}
+	}
+	return identify(ctxts, e.path, "", *coe, *nr, d, flt, gf)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadList(t *testing.T) {
+	tab := filepath.Join(t.TempDir(), "tab\tname.txt")
+	if err := os.WriteFile(tab, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []struct {
+		list string
+		nul  bool
+		want []listEntry
+	}{
+		{"a.txt\n\nb\ttext/plain\tc.txt\r\n" + tab + "\n", false, []listEntry{{path: "a.txt"}, {path: "b", mime: "text/plain", name: "c.txt"}, {path: tab}}},
+		{"new\nline.txt\x00d.bin\t\tname.doc\x00e f.pdf\tapplication/pdf\x00", true, []listEntry{{path: "new\nline.txt"}, {path: "d.bin", name: "name.doc"}, {path: "e f.pdf", mime: "application/pdf"}}},
+	} {
+		var got []listEntry
+		err := readList(strings.NewReader(v.list), v.nul, func(s string) error {
+			got = append(got, parseListEntry(s))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(v.want) {
+			t.Fatalf("expecting %v, got %v", v.want, got)
+		}
+		for i := range got {
+			if got[i] != v.want[i] {
+				t.Errorf("expecting %v, got %v", v.want[i], got[i])
+			}
+		}
+	}
+}
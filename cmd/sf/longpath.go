@@ -31,8 +31,13 @@ func tryStat(path string) error {
 	return err
 }
 
-func identify(ctxts chan *context, root, orig string, coerr, norecurse, droid bool, gf getFn) error {
+func identify(ctxts chan *context, root, orig string, coerr, norecurse, droid bool, flt *filter, gf getFn) error {
+	wk := flt.walker(root)
 	walkFunc := func(path string, info os.FileInfo, err error) error {
+		// skip paths completed by an interrupted scan (see -resume)
+		if rsm.skip(path) && (err != nil || !info.IsDir()) {
+			return nil
+		}
 		if *throttlef > 0 {
 			<-throttle.C
 		}
@@ -43,6 +48,13 @@ func identify(ctxts chan *context, root, orig string, coerr, norecurse, droid bo
 			}
 			return walkError{path, err}
 		}
+		if reason := wk.skip(path, info); reason != "" {
+			printFile(ctxts, gf(path, "", info.ModTime(), info.Size()), reason)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		if info.IsDir() {
 			if norecurse && path != root {
 				return filepath.SkipDir
@@ -84,10 +84,15 @@ func retryOpen(path string, err error) (*os.File, error) {
 	return file, nil
 }
 
-func identify(ctxts chan *context, root, orig string, coerr, norecurse, droid bool, gf getFn) error {
+func identify(ctxts chan *context, root, orig string, coerr, norecurse, droid bool, flt *filter, gf getFn) error {
+	wk := flt.walker(root)
 	walkFunc := func(path string, info os.FileInfo, err error) error {
 		var retry bool
 		var lp, sp string
+		// skip paths completed by an interrupted scan (see -resume)
+		if rsm.skip(shortpath(path, orig)) && (err != nil || !info.IsDir()) {
+			return nil
+		}
 		if *throttlef > 0 {
 			<-throttle.C
 		}
@@ -103,12 +108,19 @@ func identify(ctxts chan *context, root, orig string, coerr, norecurse, droid bo
 			lp, sp = longpath(path), path
 			retry = true
 		}
+		if reason := wk.skip(path, info); reason != "" {
+			printFile(ctxts, gf(shortpath(path, orig), "", info.ModTime(), info.Size()), reason)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		if info.IsDir() {
 			if norecurse && path != root {
 				return filepath.SkipDir
 			}
 			if retry { // if a dir long path, restart the recursion with a long path as the new root
-				return identify(ctxts, lp, sp, coerr, norecurse, droid, gf)
+				return identify(ctxts, lp, sp, coerr, norecurse, droid, flt, gf)
 			}
 			if droid {
 				printFile(ctxts, gf(shortpath(path, orig), "", info.ModTime(), -1), nil)
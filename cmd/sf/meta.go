@@ -0,0 +1,169 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// With -meta, results report further filesystem metadata for the files scanned (but not for the contents of archives):
+// birth, access and change times, the POSIX mode, the owner and group IDs and names, the inode and the link count.
+// With -xattrs, extended attributes are reported too. Fields are only reported when the platform provides them: birth
+// times need statx on Linux (and a filesystem that records them), and Windows has no change times, owners or inodes.
+// Extended attributes are reported on Linux as a space separated list of name="value" pairs.
+// The fields are extra fields, so are read back by pkg/reader with the rest of the results (e.g. for -replay).
+
+const (
+	birthField  = "birthtime"
+	accessField = "accesstime"
+	changeField = "changetime"
+	modeField   = "mode"
+	uidField    = "uid"
+	ownerField  = "owner"
+	gidField    = "gid"
+	groupField  = "group"
+	inodeField  = "inode"
+	linksField  = "links"
+	xattrsField = "xattrs"
+)
+
+var metaFields = []string{birthField, accessField, changeField, modeField, uidField, ownerField, gidField, groupField, inodeField, linksField}
+
+// fileMeta is the filesystem metadata for a file; zero values are not reported
+type fileMeta struct {
+	birth, access, change time.Time
+	mode                  os.FileMode
+	ids                   bool // uid and gid are set
+	uid, gid              uint32
+	ino, links            uint64
+	xattrs                [][2]string
+}
+
+// getMeta returns the metadata for a file as extra fields (statMeta is defined in meta_linux.go, meta_unix.go,
+// meta_windows.go and meta_other.go)
+func getMeta(path string) [][2]string {
+	m, err := statMeta(path, *xattrsf)
+	if err != nil {
+		return nil
+	}
+	ret := make([][2]string, 0, len(metaFields)+1)
+	addTime := func(k string, t time.Time) {
+		if !t.IsZero() {
+			if *utcf {
+				t = t.UTC()
+			}
+			ret = append(ret, [2]string{k, t.Format(time.RFC3339Nano)})
+		}
+	}
+	addTime(birthField, m.birth)
+	addTime(accessField, m.access)
+	addTime(changeField, m.change)
+	ret = append(ret, [2]string{modeField, posixMode(m.mode)})
+	if m.ids {
+		uid, gid := strconv.FormatUint(uint64(m.uid), 10), strconv.FormatUint(uint64(m.gid), 10)
+		ret = append(ret, [2]string{uidField, uid}, [2]string{ownerField, names.lookup(uid, false)},
+			[2]string{gidField, gid}, [2]string{groupField, names.lookup(gid, true)})
+	}
+	if m.ino > 0 {
+		ret = append(ret, [2]string{inodeField, strconv.FormatUint(m.ino, 10)})
+	}
+	if m.links > 0 {
+		ret = append(ret, [2]string{linksField, strconv.FormatUint(m.links, 10)})
+	}
+	if len(m.xattrs) > 0 {
+		sort.Slice(m.xattrs, func(i, j int) bool { return m.xattrs[i][0] < m.xattrs[j][0] })
+		attrs := make([]string, len(m.xattrs))
+		for i, x := range m.xattrs {
+			attrs[i] = x[0] + "=" + strconv.Quote(x[1])
+		}
+		ret = append(ret, [2]string{xattrsField, strings.Join(attrs, " ")})
+	}
+	return ret
+}
+
+// posixMode formats the permission, setuid, setgid and sticky bits of a file mode in octal e.g. 0644
+func posixMode(m os.FileMode) string {
+	mode := uint64(m.Perm())
+	if m&os.ModeSetuid != 0 {
+		mode |= 04000
+	}
+	if m&os.ModeSetgid != 0 {
+		mode |= 02000
+	}
+	if m&os.ModeSticky != 0 {
+		mode |= 01000
+	}
+	s := strconv.FormatUint(mode, 8)
+	return strings.Repeat("0", 4-len(s)) + s
+}
+
+// metaExtra adds metadata fields to a result's extra fields, replacing any from a previous scan (see -since)
+func metaExtra(extra, meta [][2]string) [][2]string {
+	ret := make([][2]string, 0, len(extra)+len(meta))
+	for _, e := range extra {
+		if !isMetaField(e[0]) {
+			ret = append(ret, e)
+		}
+	}
+	return append(ret, meta...)
+}
+
+func isMetaField(k string) bool {
+	if k == xattrsField {
+		return true
+	}
+	for _, f := range metaFields {
+		if k == f {
+			return true
+		}
+	}
+	return false
+}
+
+// names caches user and group names
+var names = &nameCache{m: make(map[string]string)}
+
+type nameCache struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+func (n *nameCache) lookup(id string, group bool) string {
+	key := "u" + id
+	if group {
+		key = "g" + id
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if name, ok := n.m[key]; ok {
+		return name
+	}
+	var name string
+	if group {
+		if g, err := user.LookupGroupId(id); err == nil {
+			name = g.Name
+		}
+	} else if u, err := user.LookupId(id); err == nil {
+		name = u.Username
+	}
+	n.m[key] = name
+	return name
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func statMeta(path string, xattrs bool) (fileMeta, error) {
+	var stx unix.Statx_t
+	err := unix.Statx(unix.AT_FDCWD, path, unix.AT_SYMLINK_NOFOLLOW, unix.STATX_BASIC_STATS|unix.STATX_BTIME, &stx)
+	if err != nil {
+		return fileMeta{}, err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fileMeta{}, err
+	}
+	m := fileMeta{
+		access: statxTime(stx.Atime),
+		change: statxTime(stx.Ctime),
+		mode:   info.Mode(),
+		ids:    true,
+		uid:    stx.Uid,
+		gid:    stx.Gid,
+		ino:    stx.Ino,
+		links:  uint64(stx.Nlink),
+	}
+	if stx.Mask&unix.STATX_BTIME != 0 {
+		m.birth = statxTime(stx.Btime)
+	}
+	if xattrs {
+		m.xattrs = listXattrs(path)
+	}
+	return m, nil
+}
+
+func statxTime(ts unix.StatxTimestamp) time.Time {
+	return time.Unix(ts.Sec, int64(ts.Nsec))
+}
+
+// listXattrs returns a file's extended attributes (any that can't be read are skipped)
+func listXattrs(path string) [][2]string {
+	sz, err := unix.Llistxattr(path, nil)
+	if err != nil || sz == 0 {
+		return nil
+	}
+	buf := make([]byte, sz)
+	if sz, err = unix.Llistxattr(path, buf); err != nil {
+		return nil
+	}
+	var ret [][2]string
+	for _, name := range strings.Split(strings.TrimSuffix(string(buf[:sz]), "\x00"), "\x00") {
+		vsz, err := unix.Lgetxattr(path, name, nil)
+		if err != nil {
+			continue
+		}
+		val := make([]byte, vsz)
+		if vsz, err = unix.Lgetxattr(path, name, val); err != nil {
+			continue
+		}
+		ret = append(ret, [2]string{name, string(val[:vsz])})
+	}
+	return ret
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPosixMode(t *testing.T) {
+	for _, v := range []struct {
+		mode os.FileMode
+		want string
+	}{
+		{0644, "0644"},
+		{0755 | os.ModeSetuid, "4755"},
+		{0775 | os.ModeSetgid | os.ModeDir, "2775"},
+		{0777 | os.ModeSticky | os.ModeDir, "1777"},
+		{0, "0000"},
+	} {
+		if got := posixMode(v.mode); got != v.want {
+			t.Errorf("expecting %s for %v, got %s", v.want, v.mode, got)
+		}
+	}
+}
+
+func TestMetaExtra(t *testing.T) {
+	extra := [][2]string{{"fixity", "ok"}, {modeField, "0600"}, {reusedField, "true"}}
+	got := metaExtra(extra, [][2]string{{modeField, "0644"}})
+	want := [][2]string{{"fixity", "ok"}, {reusedField, "true"}, {modeField, "0644"}}
+	if len(got) != len(want) {
+		t.Fatalf("expecting %v, got %v", want, got)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("expecting %v, got %v", want[i], got[i])
+		}
+	}
+}
+
+func TestGetMeta(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "meta.txt")
+	if err := os.WriteFile(path, []byte("meta"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	meta := getMeta(path)
+	var mode string
+	for _, m := range meta {
+		if !isMetaField(m[0]) {
+			t.Errorf("unexpected field %s", m[0])
+		}
+		if m[0] == modeField {
+			mode = m[1]
+		}
+	}
+	if mode == "" {
+		t.Fatalf("expecting a mode, got %v", meta)
+	}
+	if getMeta(filepath.Join(t.TempDir(), "missing")) != nil {
+		t.Error("expecting no metadata for a missing file")
+	}
+}
@@ -0,0 +1,39 @@
+//go:build darwin || freebsd || netbsd
+
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func statMeta(path string, xattrs bool) (fileMeta, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fileMeta{}, err
+	}
+	m := fileMeta{mode: info.Mode()}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		m.birth = time.Unix(st.Birthtimespec.Unix())
+		m.access = time.Unix(st.Atimespec.Unix())
+		m.change = time.Unix(st.Ctimespec.Unix())
+		m.ids, m.uid, m.gid = true, st.Uid, st.Gid
+		m.ino, m.links = uint64(st.Ino), uint64(st.Nlink)
+	}
+	return m, nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func statMeta(path string, xattrs bool) (fileMeta, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fileMeta{}, err
+	}
+	m := fileMeta{mode: info.Mode()}
+	if fa, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		m.birth = time.Unix(0, fa.CreationTime.Nanoseconds())
+		m.access = time.Unix(0, fa.LastAccessTime.Nanoseconds())
+	}
+	return m, nil
+}
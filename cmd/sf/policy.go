@@ -0,0 +1,295 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+	"github.com/richardlehane/siegfried/pkg/sets"
+)
+
+// With -policy, each result (including the contents of archives) is checked against a policy file and annotated with a
+// "policy" field, and sf's exit code reports any problems. A policy file is a simple YAML file e.g.
+//
+//	allow: [fmt/18, fmt/19, '@pdfa']    # formats that are allowed; if given, other formats fail
+//	deny:                               # formats that fail
+//	  - fmt/14
+//	maxunknowns: 0                      # the number of unknowns permitted (no limit if not given)
+//	warnings:                           # warnings that fail or warn, matched by their text
+//	  extension mismatch: fail
+//	  match on extension only: warn
+//	errors: fail                        # fail (the default), warn or ignore files with errors
+//
+// Format IDs can be given as sets (see roy sets). The policy field is "pass", "unknown", "error", or "warn" or "fail"
+// followed by a reason. The exit code adds policyFail if any results fail, policyUnknowns if there are more unknowns than
+// permitted, and policyErrors if any files have errors (e.g. 6 for failures and too many unknowns).
+
+// pol is nil unless the -policy flag is given
+var pol *policy
+
+// policyField is the extra field that reports a result's policy verdict
+const policyField = "policy"
+
+// exit codes
+const (
+	policyFail     = 2
+	policyUnknowns = 4
+	policyErrors   = 8
+)
+
+// policy actions
+const (
+	actFail   = "fail"
+	actWarn   = "warn"
+	actIgnore = "ignore"
+)
+
+type policy struct {
+	allow       map[string]bool
+	deny        map[string]bool
+	maxUnknowns int         // -1 for no limit
+	warnings    [][2]string // warning text and action
+	errors      string      // action for files with errors
+	counts      map[string]int
+}
+
+func loadPolicy(path string) (*policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p, err := parsePolicy(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return p, nil
+}
+
+// parsePolicy reads the subset of YAML used by policy files: top-level settings with a value, a list (in flow or block
+// style), or a block of settings.
+func parsePolicy(r io.Reader) (*policy, error) {
+	p := &policy{maxUnknowns: -1, errors: actFail, counts: make(map[string]int)}
+	var (
+		key  string
+		list []string
+	)
+	end := func() error {
+		switch key {
+		case "allow":
+			p.allow = formats(list)
+		case "deny":
+			p.deny = formats(list)
+		case "", "warnings":
+		default:
+			if len(list) > 0 {
+				return fmt.Errorf("%s isn't a list", key)
+			}
+		}
+		list = nil
+		return nil
+	}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx == 0 || (idx > 0 && (line[idx-1] == ' ' || line[idx-1] == '\t')) {
+			line = line[:idx]
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indented := line[0] == ' ' || line[0] == '\t'
+		line = strings.TrimSpace(line)
+		if indented && key == "" {
+			return nil, fmt.Errorf("line %d: unexpected indentation", n)
+		}
+		if indented && strings.HasPrefix(line, "-") {
+			list = append(list, unquote(strings.TrimPrefix(line, "-")))
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expecting a setting e.g. maxunknowns: 0", n)
+		}
+		k, v = unquote(k), strings.TrimSpace(v)
+		if indented {
+			if key != "warnings" {
+				return nil, fmt.Errorf("line %d: %s doesn't have settings", n, key)
+			}
+			act := unquote(v)
+			if act != actFail && act != actWarn && act != actIgnore {
+				return nil, fmt.Errorf("line %d: bad action %s, expecting fail, warn or ignore", n, act)
+			}
+			p.warnings = append(p.warnings, [2]string{strings.ToLower(k), act})
+			continue
+		}
+		if err := end(); err != nil {
+			return nil, err
+		}
+		key = k
+		switch key {
+		case "allow", "deny":
+			if strings.HasPrefix(v, "[") {
+				v = strings.TrimSuffix(strings.TrimPrefix(v, "["), "]")
+			}
+			for _, item := range strings.Split(v, ",") {
+				if item = unquote(item); item != "" {
+					list = append(list, item)
+				}
+			}
+		case "maxunknowns":
+			i, err := strconv.Atoi(unquote(v))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad maxunknowns, expecting a number", n)
+			}
+			p.maxUnknowns = i
+		case "errors":
+			p.errors = unquote(v)
+			if p.errors != actFail && p.errors != actWarn && p.errors != actIgnore {
+				return nil, fmt.Errorf("line %d: bad action %s, expecting fail, warn or ignore", n, p.errors)
+			}
+		case "warnings":
+			if v != "" {
+				return nil, fmt.Errorf("line %d: expecting warnings to be followed by indented settings", n)
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unknown setting %s", n, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return p, end()
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > 1 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// formats expands any sets in a list of format IDs
+func formats(list []string) map[string]bool {
+	ret := make(map[string]bool)
+	for _, v := range sets.Sets(list...) {
+		ret[v] = true
+	}
+	return ret
+}
+
+// verdict checks a result against the policy
+func (p *policy) verdict(err error, ids []core.Identification) string {
+	v := p.check(err, ids)
+	status, _, _ := strings.Cut(v, ":")
+	p.counts[status]++
+	return v
+}
+
+func (p *policy) check(err error, ids []core.Identification) string {
+	var known string // the first known ID
+	for _, id := range ids {
+		if id.Known() {
+			if known == "" {
+				known = id.String()
+			}
+			if p.deny[id.String()] {
+				return actFail + ": denied " + id.String()
+			}
+		}
+	}
+	if known != "" && len(p.allow) > 0 {
+		var allowed bool
+		for _, id := range ids {
+			if p.allow[id.String()] {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return actFail + ": not allowed " + known
+		}
+	}
+	if err != nil && p.errors != actIgnore {
+		if p.errors == actWarn {
+			return actWarn + ": error"
+		}
+		return "error"
+	}
+	if known == "" {
+		return "unknown"
+	}
+	var warn string
+	for _, id := range ids {
+		w := strings.ToLower(id.Warn())
+		for _, rule := range p.warnings {
+			if !strings.Contains(w, rule[0]) {
+				continue
+			}
+			switch rule[1] {
+			case actFail:
+				return actFail + ": " + rule[0]
+			case actWarn:
+				if warn == "" {
+					warn = actWarn + ": " + rule[0]
+				}
+			}
+		}
+	}
+	if warn != "" {
+		return warn
+	}
+	return "pass"
+}
+
+// policyExtra adds the policy field to a result's extra fields
+func policyExtra(extra [][2]string, verdict string) [][2]string {
+	ret := make([][2]string, 0, len(extra)+1)
+	for _, e := range extra {
+		if e[0] != policyField {
+			ret = append(ret, e)
+		}
+	}
+	return append(ret, [2]string{policyField, verdict})
+}
+
+// exitCode reports policy failures, too many unknowns and errors
+func (p *policy) exitCode() int {
+	var code int
+	if p.counts[actFail] > 0 {
+		code += policyFail
+	}
+	if p.maxUnknowns >= 0 && p.counts["unknown"] > p.maxUnknowns {
+		code += policyUnknowns
+	}
+	if p.counts["error"] > 0 {
+		code += policyErrors
+	}
+	return code
+}
+
+// summary logs the number of results with each verdict
+func (p *policy) summary() {
+	log.Printf("[POLICY] %d pass, %d warn, %d fail, %d unknown, %d error (exit code %d)",
+		p.counts["pass"], p.counts[actWarn], p.counts[actFail], p.counts["unknown"], p.counts["error"], p.exitCode())
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+	"github.com/richardlehane/siegfried/pkg/pronom"
+)
+
+const testPolicy = `# test policy
+allow: [fmt/95, "fmt/96"]
+deny:
+  - fmt/14 # not allowed
+maxunknowns: 1
+warnings:
+  extension mismatch: fail
+  'match on extension only': warn
+errors: fail
+`
+
+func TestPolicy(t *testing.T) {
+	p, err := parsePolicy(strings.NewReader(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := func(puid, warn string) []core.Identification {
+		return []core.Identification{pronom.Identification{Namespace: "pronom", ID: puid, Warning: warn}}
+	}
+	for _, v := range []struct {
+		err  error
+		ids  []core.Identification
+		want string
+	}{
+		{nil, id("fmt/96", ""), "pass"},
+		{nil, id("fmt/14", ""), "fail: denied fmt/14"},
+		{nil, id("fmt/18", ""), "fail: not allowed fmt/18"},
+		{nil, id("fmt/95", "extension mismatch"), "fail: extension mismatch"},
+		{nil, id("fmt/95", "match on extension only"), "warn: match on extension only"},
+		{nil, id("UNKNOWN", "no match"), "unknown"},
+		{nil, id("UNKNOWN", "no match"), "unknown"},
+		{errors.New("empty source"), id("UNKNOWN", "no match"), "error"},
+	} {
+		if got := p.verdict(v.err, v.ids); got != v.want {
+			t.Errorf("%v: expecting %s, got %s", v.ids, v.want, got)
+		}
+	}
+	if code := p.exitCode(); code != policyFail+policyUnknowns+policyErrors {
+		t.Errorf("expecting exit code 14, got %d", code)
+	}
+	for _, bad := range []string{"maxunknowns: none\n", "allow: fmt/1\n  extension mismatch: fail\n", "colour: red\n", "warnings:\n  no match: explode\n"} {
+		if _, err := parsePolicy(strings.NewReader(bad)); err == nil {
+			t.Errorf("expecting an error for %q", bad)
+		}
+	}
+}
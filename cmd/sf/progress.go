@@ -0,0 +1,81 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/richardlehane/siegfried/internal/logger"
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+// With -log eta, or -progressfd, progress is reported each second with the files and bytes scanned, throughput and,
+// once the files to be scanned have been counted, an estimated time to completion (see logger.Logger).
+// The files are counted by a walk that runs alongside the scan and applies the same filters. The contents of archives
+// aren't counted or reported. There are no totals for files read from stdin or lists (-f), for replays, or when watching a directory (-watch).
+
+// countFiles counts the files and bytes that will be scanned in a set of paths and sets the logger's totals
+func countFiles(lg *logger.Logger, paths []string, norecurse bool, flt *filter) {
+	var files, bytes int64
+	for _, root := range paths {
+		wk := flt.walker(root)
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if rsm.skip(path) && (err != nil || !info.IsDir()) {
+				return nil
+			}
+			if err != nil {
+				files++ // reported as an error
+				return nil
+			}
+			if reason := wk.skip(path, info); reason != "" {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() {
+				if norecurse && path != root {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			files++
+			bytes += info.Size()
+			return nil
+		})
+	}
+	lg.SetTotal(files, bytes)
+}
+
+// countPaths returns the paths to count for progress totals, or nil if there can be no totals
+func countPaths() []string {
+	if *replay || *list || *watchf {
+		return nil
+	}
+	if bg != nil {
+		if bg.arc != config.None {
+			return nil
+		}
+		return []string{filepath.Join(bg.path, filepath.FromSlash(bagPayload))}
+	}
+	for _, v := range flag.Args() {
+		if v == "-" {
+			return nil
+		}
+	}
+	return flag.Args()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/logger"
+)
+
+func TestCountFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a.txt": "hello", "b.tmp": "skipped", "sub/c.txt": "world!"} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lg, _ := logger.New("")
+	buf := &bytes.Buffer{}
+	lg.ProgressJSON(buf)
+	countFiles(lg, []string{dir}, false, &filter{exclude: patterns{"*.tmp"}})
+	lg.Done(5)
+	lg.Close()
+	var report struct {
+		Files, Bytes, TotalFiles, TotalBytes int64
+		Percent                              float64
+		Done                                 bool
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.TotalFiles != 2 || report.TotalBytes != 11 || report.Files != 1 || report.Bytes != 5 || !report.Done {
+		t.Errorf("bad progress report: %s", buf)
+	}
+	if report.Percent < 45 || report.Percent > 46 {
+		t.Errorf("expecting 45%% done, got %f", report.Percent)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/reader"
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+// With -resume, results are written to a file and the paths that have been completed are appended to a journal
+// (the results file name with a ".journal" extension). If a scan is interrupted, running the same command again
+// resumes it: results for journaled paths are read back from the interrupted results file and written out again,
+// the directory walk skips those paths, and new results follow. The journal is removed when a scan completes.
+//
+// Writers buffer their output, so a journaled path may not have made it into the results file before the interruption,
+// and the contents of an archive may have been cut short. Paths are only skipped if they are journaled and their results
+// were written in full.
+
+// rsm is nil unless the -resume flag is given
+var rsm *resumer
+
+type resumer struct {
+	path      string
+	out       *os.File
+	journal   *os.File
+	prev      *os.File        // the interrupted results file, if resuming
+	rdr       reader.Reader   // reads prev
+	journaled map[string]bool // paths journaled by the interrupted scan
+	done      map[string]bool // paths with results from the interrupted scan
+}
+
+func journalName(path string) string { return path + ".journal" }
+
+// results are written to a temporary file when resuming, and replace the interrupted results when the scan completes
+func tmpName(path string) string { return path + ".tmp" }
+
+func newResumer(path string) (*resumer, error) {
+	r := &resumer{path: path, done: make(map[string]bool)}
+	journaled, err := readJournal(journalName(path))
+	if err != nil {
+		return nil, err
+	}
+	if len(journaled) > 0 {
+		// a temporary results file is left behind if a resumed scan was itself interrupted
+		if _, err = os.Stat(tmpName(path)); err == nil {
+			if err = os.Rename(tmpName(path), path); err != nil {
+				return nil, err
+			}
+		}
+		if r.prev, err = os.Open(path); err == nil {
+			if r.rdr, err = reader.New(r.prev, path); err != nil {
+				// nothing was written before the interruption, so start again
+				r.prev.Close()
+				r.prev, r.rdr = nil, nil
+			}
+		}
+		r.journaled = journaled
+	}
+	name, flags := path, os.O_WRONLY|os.O_CREATE|os.O_APPEND
+	if r.rdr != nil {
+		name = tmpName(path)
+	} else {
+		flags |= os.O_TRUNC
+	}
+	if r.out, err = os.Create(name); err != nil {
+		r.close(false)
+		return nil, err
+	}
+	if r.journal, err = os.OpenFile(journalName(path), flags, 0644); err != nil {
+		r.close(false)
+		return nil, err
+	}
+	return r, nil
+}
+
+func readJournal(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	ret := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		ret[scanner.Text()] = true
+	}
+	return ret, scanner.Err()
+}
+
+// head returns the header of the interrupted results file, if resuming
+func (r *resumer) head() (reader.Head, bool) {
+	if r == nil || r.rdr == nil {
+		return reader.Head{}, false
+	}
+	return r.rdr.Head(), true
+}
+
+// replay writes the results of the interrupted scan for journaled paths, and the contents of those paths if they are archives.
+// The last path in the interrupted results file is scanned again as its results may be incomplete.
+func (r *resumer) replay(w writer.Writer) {
+	if r.rdr == nil {
+		return
+	}
+	var group []reader.File // a journaled path, followed by its contents
+	for rf, err := r.rdr.Next(); err == nil; rf, err = r.rdr.Next() {
+		if len(group) > 0 && strings.HasPrefix(rf.Path, group[0].Path+"#") {
+			group = append(group, rf)
+			continue
+		}
+		for i, f := range group {
+			w.File(f.Path, f.Size, f.Mod.Format(time.RFC3339), f.Hash, f.Err, f.IDs, f.Extra...)
+			r.done[f.Path] = true
+			if i == 0 && vrf != nil {
+				vrf.replayed(f.Path, f.Extra)
+			}
+			if dup != nil {
+				dup.Add(f.Path, f.Size, f.Hash)
+			}
+		}
+		group = group[:0]
+		if r.journaled[rf.Path] {
+			group = append(group, rf)
+		}
+	}
+	r.prev.Close()
+}
+
+// skip reports whether a path was completed by the interrupted scan
+func (r *resumer) skip(path string) bool {
+	return r != nil && r.done[path]
+}
+
+// record appends a completed path to the journal
+func (r *resumer) record(path string) {
+	io.WriteString(r.journal, path+"\n")
+}
+
+// close closes the results file and journal. If the scan is complete, the journal is removed.
+func (r *resumer) close(complete bool) error {
+	if r.journal != nil {
+		r.journal.Close()
+	}
+	if r.out == nil {
+		return nil
+	}
+	if err := r.out.Close(); err != nil || !complete {
+		return err
+	}
+	if r.rdr != nil {
+		if err := os.Rename(tmpName(r.path), r.path); err != nil {
+			return err
+		}
+	}
+	return os.Remove(journalName(r.path))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+	"github.com/richardlehane/siegfried/pkg/pronom"
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+func TestResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.yaml")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := [][]string{{"namespace", "id", "format", "version", "mime", "class", "basis", "warning"}}
+	ids := []core.Identification{pronom.Identification{Namespace: "pronom", ID: "fmt/1"}}
+	w := writer.YAML(f)
+	w.Head("default.sig", time.Now(), time.Now(), [3]int{1, 11, 1}, [][2]string{{"pronom", ""}}, fields, "")
+	for _, p := range []string{"a", "b.zip", "b.zip#1", "b.zip#2", "c", "d.zip", "d.zip#1"} {
+		w.File(p, 1, "", nil, nil, ids)
+	}
+	w.Tail()
+	f.Close()
+	// c wasn't journaled; d.zip is the last path in the results, so its contents may be incomplete
+	if err = os.WriteFile(journalName(path), []byte("a\nb.zip\nd.zip\ne\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := newResumer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.head(); !ok {
+		t.Fatal("expecting to resume")
+	}
+	buf := &bytes.Buffer{}
+	w = writer.YAML(buf)
+	w.Head("default.sig", time.Now(), time.Now(), [3]int{1, 11, 1}, [][2]string{{"pronom", ""}}, fields, "")
+	r.replay(w)
+	w.Tail()
+	for _, p := range []string{"a", "b.zip", "b.zip#1", "b.zip#2"} {
+		if !r.skip(p) || !strings.Contains(buf.String(), "filename : '"+p+"'") {
+			t.Errorf("expecting %s to be resumed", p)
+		}
+	}
+	for _, p := range []string{"c", "d.zip", "d.zip#1", "e"} {
+		if r.skip(p) || strings.Contains(buf.String(), "filename : '"+p+"'") {
+			t.Errorf("expecting %s to be scanned again", p)
+		}
+	}
+	r.record("c")
+	if err = r.close(true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(journalName(path)); !os.IsNotExist(err) {
+		t.Errorf("expecting the journal to be removed, got %v", err)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
@@ -49,10 +50,10 @@ func decodePath(s, b64 string) (string, error) {
 	return s[10:], nil
 }
 
-func parseRequest(w http.ResponseWriter, r *http.Request, s *siegfried.Siegfried, wg *sync.WaitGroup) (string, writer.Writer, bool, bool, bool, checksum.HashTyp, *siegfried.Siegfried, getFn, error) {
+func parseRequest(w http.ResponseWriter, r *http.Request, s *siegfried.Siegfried, wg *sync.WaitGroup) (string, writer.Writer, bool, bool, bool, *filter, checksum.HashTyps, []string, *siegfried.Siegfried, getFn, error) {
 	// json, csv, droid or yaml
-	paramsErr := func(field, expect string) (string, writer.Writer, bool, bool, bool, checksum.HashTyp, *siegfried.Siegfried, getFn, error) {
-		return "", nil, false, false, false, -1, nil, nil, fmt.Errorf("bad request; in param %s got %s; valid values %s", field, r.FormValue(field), expect)
+	paramsErr := func(field, expect string) (string, writer.Writer, bool, bool, bool, *filter, checksum.HashTyps, []string, *siegfried.Siegfried, getFn, error) {
+		return "", nil, false, false, false, nil, nil, nil, nil, nil, fmt.Errorf("bad request; in param %s got %s; valid values %s", field, r.FormValue(field), expect)
 	}
 	var (
 		mime string
@@ -133,6 +134,51 @@ func parseRequest(w http.ResponseWriter, r *http.Request, s *siegfried.Siegfried
 			paramsErr("coe", "true or false")
 		}
 	}
+	// directory walk filters
+	flt := flagFilter()
+	if v := r.FormValue("include"); v != "" {
+		flt.include = nil
+		for _, p := range r.Form["include"] {
+			flt.include.Set(p)
+		}
+	}
+	if v := r.FormValue("exclude"); v != "" {
+		flt.exclude = nil
+		for _, p := range r.Form["exclude"] {
+			flt.exclude.Set(p)
+		}
+	}
+	if v := r.FormValue("maxdepth"); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return paramsErr("maxdepth", "an integer")
+		}
+		flt.maxDepth = i
+	}
+	if v := r.FormValue("skiphidden"); v != "" {
+		switch v {
+		case "true":
+			flt.hidden = true
+		case "false":
+			flt.hidden = false
+		default:
+			return paramsErr("skiphidden", "true or false")
+		}
+	}
+	if v := r.FormValue("minsize"); v != "" {
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return paramsErr("minsize", "an integer")
+		}
+		flt.minSize = i
+	}
+	if v := r.FormValue("maxsize"); v != "" {
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return paramsErr("maxsize", "an integer")
+		}
+		flt.maxSize = i
+	}
 	// archive
 	z := *archive
 	if v := r.FormValue("z"); v != "" {
@@ -145,17 +191,50 @@ func parseRequest(w http.ResponseWriter, r *http.Request, s *siegfried.Siegfried
 			paramsErr("z", "true or false")
 		}
 	}
+	// decompression limits
+	lim := limits()
+	if v := r.FormValue("zdepth"); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return paramsErr("zdepth", "an integer")
+		}
+		lim.Depth = i
+	}
+	if v := r.FormValue("zratio"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return paramsErr("zratio", "a number")
+		}
+		lim.Ratio = f
+	}
+	if v := r.FormValue("zbytes"); v != "" {
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return paramsErr("zbytes", "an integer")
+		}
+		lim.Bytes = i
+	}
+	if v := r.FormValue("zentries"); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return paramsErr("zentries", "an integer")
+		}
+		lim.Entries = i
+	}
 	// checksum
 	h := *hashf
 	if v := r.FormValue("hash"); v != "" {
 		h = v
 	}
-	ht := checksum.GetHash(h)
+	ht, err := checksum.GetHashes(h)
+	if err != nil {
+		return paramsErr("hash", checksum.HashChoices)
+	}
 	// sig
 	sf := s
 	if v := r.FormValue("sig"); v != "" {
 		if _, err := os.Stat(config.Local(v)); err != nil {
-			return "", nil, false, false, false, -1, nil, nil, fmt.Errorf("bad request; sig param should be path to a signature file (absolute or relative to home); got %v", err)
+			return "", nil, false, false, false, nil, nil, nil, nil, nil, fmt.Errorf("bad request; sig param should be path to a signature file (absolute or relative to home); got %v", err)
 		}
 		nsf, err := siegfried.Load(config.Local(v))
 		if err == nil {
@@ -164,18 +243,18 @@ func parseRequest(w http.ResponseWriter, r *http.Request, s *siegfried.Siegfried
 	}
 	gf := func(path, mime string, mod time.Time, sz int64) *context {
 		c := ctxPool.Get().(*context)
-		c.path, c.mime, c.mod, c.sz = path, mime, mod, sz
-		c.s, c.wg, c.w, c.d, c.z, c.h = sf, wg, wr, d, z, checksum.MakeHash(ht)
+		c.path, c.mime, c.mod, c.sz, c.depth, c.parent, c.extra, c.prev, c.meta, c.lerr = path, mime, mod, sz, 0, "", nil, nil, nil, nil
+		c.s, c.wg, c.w, c.d, c.z, c.lim, c.ht, c.h = sf, wg, wr, d, z, lim, ht, checksum.MakeHashes(ht)
 		return c
 	}
-	return mime, wr, coerr, norec, d, ht, sf, gf, nil
+	return mime, wr, coerr, norec, d, flt, ht, extraFields(z), sf, gf, nil
 }
 
 func handleIdentify(w http.ResponseWriter, r *http.Request, s *siegfried.Siegfried, ctxts chan *context) {
 	wg := &sync.WaitGroup{}
-	mime, wr, coerr, nrec, d, ht, sf, gf, err := parseRequest(w, r, s, wg)
+	mime, wr, coerr, nrec, d, flt, ht, ex, sf, gf, err := parseRequest(w, r, s, wg)
 	if err != nil {
-		handleErr(w, http.StatusNotFound, err)
+		handleErr(w, http.StatusBadRequest, err)
 		return
 	}
 	if r.Method == "POST" {
@@ -199,11 +278,11 @@ func handleIdentify(w http.ResponseWriter, r *http.Request, s *siegfried.Siegfri
 			sz = r.ContentLength
 		}
 		w.Header().Set("Content-Type", mime)
-		wr.Head(config.SignatureBase(), time.Now(), sf.C, config.Version(), sf.Identifiers(), sf.Fields(), ht.String())
+		wr.Head(config.SignatureBase(), time.Now(), sf.C, config.Version(), sf.Identifiers(), sf.Fields(), ht.String(), ex...)
 		wg.Add(1)
 		ctx := gf(h.Filename, "", mod, sz)
 		ctxts <- ctx
-		identifyRdr(f, ctx, ctxts, gf)
+		identifyRdr(f, ctx, gf)
 		wg.Wait()
 		wr.Tail()
 		return
@@ -217,8 +296,8 @@ func handleIdentify(w http.ResponseWriter, r *http.Request, s *siegfried.Siegfri
 		return
 	}
 	w.Header().Set("Content-Type", mime)
-	wr.Head(config.SignatureBase(), time.Now(), sf.C, config.Version(), sf.Identifiers(), sf.Fields(), ht.String())
-	err = identify(ctxts, path, "", coerr, nrec, d, gf)
+	wr.Head(config.SignatureBase(), time.Now(), sf.C, config.Version(), sf.Identifiers(), sf.Fields(), ht.String(), ex...)
+	err = identify(ctxts, path, "", coerr, nrec, d, flt, gf)
 	wg.Wait()
 	wr.Tail()
 	if _, ok := err.(walkError); ok { // only dump out walk errors, other errors reported in result
@@ -239,7 +318,7 @@ const usage = `
 			<p>The update command can also be issued as a GET request to <a href="/update">/update</a>. This fetches an updated signature file and hot patches the running siegfried instance.</p>
 			<p>If PRONOM isn't being used as the underlying identifier, the update command can be qualified with the name of a different identifer e.g. <a href="/update">/update/wikidata</a>.</p>
 			<h2>Default settings</h2>
-			<p>When starting the server, you can use regular sf flags to set defaults for the <i>nr</i>, <i>format</i>, <i>hash</i>, <i>z</i>, and <i>sig</i> parameters that will apply to all requests unless overridden. Logging options can also be set.<p>
+			<p>When starting the server, you can use regular sf flags to set defaults for the <i>nr</i>, <i>include</i>, <i>exclude</i>, <i>maxdepth</i>, <i>skiphidden</i>, <i>minsize</i>, <i>maxsize</i>, <i>format</i>, <i>hash</i>, <i>z</i>, <i>zdepth</i>, <i>zratio</i>, <i>zbytes</i>, <i>zentries</i>, and <i>sig</i> parameters that will apply to all requests unless overridden. Logging options can also be set.<p>
 			<p>E.g. sf -nr -z -hash md5 -sig pronom-tika.sig -log p,w,e -serve localhost:5138</p>
 			<hr>
 			<h2><a name="get_request">GET request</a></h2>
@@ -249,9 +328,14 @@ const usage = `
 			<p><i>base64</i> (optional) - use <a href="https://tools.ietf.org/html/rfc4648#section-5">URL-safe base64 encoding</a> for the file or folder name with base64=true.</p>
 			<p><i>coe</i> (optional) - continue directory scans even when fatal file access errors are encountered with coe=true.</p>
 			<p><i>nr</i> (optional) - stop sub-directory recursion when a directory path is given with nr=true.</p>
+			<p><i>include</i> and <i>exclude</i> (optional) - when a directory path is given, only scan files matching, or skip files and directories matching, glob patterns e.g. include=*.pdf&exclude=cache/. Patterns without a slash are matched against file names, others against paths relative to the directory. Both can be repeated. Exclude patterns are also read from .sfignore files.</p>
+			<p><i>maxdepth</i> (optional) - limit the depth of directory recursion e.g. maxdepth=2. Use 0 for no limit.</p>
+			<p><i>skiphidden</i> (optional) - skip hidden (dot) files and directories with skiphidden=true.</p>
+			<p><i>minsize</i> and <i>maxsize</i> (optional) - skip files smaller or larger than a size in bytes. Use 0 for no maximum size.</p>
 			<p><i>format</i> (optional) - select the output format (csv, yaml, json, droid). Default is yaml. Alternatively, HTTP content negotiation can be used.</p>
-			<p><i>hash</i> (optional) - calculate file checksum (md5, sha1, sha256, sha512, crc)</p>
-			<p><i>z</i> (optional) - scan archive formats (zip, tar, gzip, warc, arc) with z=true. Default is false.</p>
+			<p><i>hash</i> (optional) - calculate file checksums (md5, sha1, sha256, sha512, sha3-256, blake2b-512, blake3, xxh64, crc; or a comma separated list e.g. md5,sha256)</p>
+			<p><i>z</i> (optional) - scan archive formats (zip, tar, gzip, bzip2, xz, zstd, warc, arc, 7z, iso, ar, cpio, rpm, mbox, eml, msg) with z=true. Default is false.</p>
+			<p><i>zdepth</i>, <i>zratio</i>, <i>zbytes</i> and <i>zentries</i> (optional) - limit the nesting depth of archives, the ratio of bytes unpacked from an archive to its size, the bytes unpacked from an archive and the number of entries unpacked from an archive when z=true e.g. zdepth=4&zbytes=1000000000. Use 0 for no limit. Defaults are set by the equivalent sf flags.</p>
 			<p><i>sig</i> (optional) - load a specific signature file. Default is default.sig.</p>
 			<h3>Example</h2>
 			<!-- set the get target for the example form using js function at bottom page-->
@@ -274,6 +358,10 @@ const usage = `
   				<option value="sha1">sha1</option>
  				<option value="sha256">sha256</option>
  				<option value="sha512">sha512</option>
+ 				<option value="sha3-256">sha3-256</option>
+ 				<option value="blake2b-512">blake2b-512</option>
+ 				<option value="blake3">blake3</option>
+ 				<option value="xxh64">xxh64</option>
  				<option value="crc">crc</option>
 			</select></p>
 			 <p>Scan archive (z): <input type="radio" name="z" value="true"> true <input type="radio" name="z" value="false" checked> false</p>
@@ -287,8 +375,9 @@ const usage = `
 			<p>E.g. curl "http://localhost:5138/identify?format=json&hash=crc" -F file=@myfile.doc</p>
 			<h3>Parameters</h3>
 			<p><i>format</i> (optional) - select the output format (csv, yaml, json, droid). Default is yaml. Alternatively, HTTP content negotiation can be used.</p>
-			<p><i>hash</i> (optional) - calculate file checksum (md5, sha1, sha256, sha512, crc)</p>
-			<p><i>z</i> (optional) - scan archive formats (zip, tar, gzip, warc, arc) with z=true. Default is false.</p>
+			<p><i>hash</i> (optional) - calculate file checksums (md5, sha1, sha256, sha512, sha3-256, blake2b-512, blake3, xxh64, crc; or a comma separated list e.g. md5,sha256)</p>
+			<p><i>z</i> (optional) - scan archive formats (zip, tar, gzip, bzip2, xz, zstd, warc, arc, 7z, iso, ar, cpio, rpm, mbox, eml, msg) with z=true. Default is false.</p>
+			<p><i>zdepth</i>, <i>zratio</i>, <i>zbytes</i> and <i>zentries</i> (optional) - limit the nesting depth of archives, the ratio of bytes unpacked from an archive to its size, the bytes unpacked from an archive and the number of entries unpacked from an archive when z=true e.g. zdepth=4&zbytes=1000000000. Use 0 for no limit. Defaults are set by the equivalent sf flags.</p>
 			<p><i>sig</i> (optional) - load a specific signature file. Default is default.sig.</p>
 			<h3>Example</h2>
 			<form action="/identify" enctype="multipart/form-data" method="post">
@@ -307,6 +396,10 @@ const usage = `
   				<option value="sha1">sha1</option>
  				<option value="sha256">sha256</option>
  				<option value="sha512">sha512</option>
+ 				<option value="sha3-256">sha3-256</option>
+ 				<option value="blake2b-512">blake2b-512</option>
+ 				<option value="blake3">blake3</option>
+ 				<option value="xxh64">xxh64</option>
  				<option value="crc">crc</option>
 			</select></p>
 			 <p>Scan archive (z): <input type="radio" name="z" value="true"> true <input type="radio" name="z" value="false" checked> false</p>
@@ -15,7 +15,7 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
 	"errors"
 	"flag"
 	"fmt"
@@ -23,15 +23,18 @@ import (
 	"io"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/richardlehane/siegfried"
 	"github.com/richardlehane/siegfried/internal/checksum"
 	"github.com/richardlehane/siegfried/internal/logger"
+	"github.com/richardlehane/siegfried/internal/siegreader"
 	"github.com/richardlehane/siegfried/pkg/config"
 	"github.com/richardlehane/siegfried/pkg/core"
 	"github.com/richardlehane/siegfried/pkg/decompress"
@@ -48,8 +51,13 @@ var (
 	update         = flag.Bool("update", false, "update or install the default signature file")
 	versionShort   = flag.Bool("v", false, "display version information")
 	version        = flag.Bool("version", false, "display version information")
-	logf           = flag.String("log", "error", "log errors, warnings, debug or slow output, knowns or unknowns to stderr or stdout e.g. -log error,warn,unknown,stdout")
+	logf           = flag.String("log", "error", "log errors, warnings, debug or slow output, knowns, unknowns or skipped files to stderr or stdout e.g. -log error,warn,unknown,stdout")
+	progressfd     = flag.Int("progressfd", 0, "write progress reports with totals and an ETA as JSON lines to a file descriptor e.g. -progressfd 3")
 	nr             = flag.Bool("nr", false, "prevent automatic directory recursion")
+	maxdepth       = flag.Int("maxdepth", 0, "limit the depth of directory recursion e.g. -maxdepth 2 scans files in a directory and its sub-directories (0 for no limit)")
+	skiphidden     = flag.Bool("skiphidden", false, "skip hidden (dot) files and directories")
+	minsize        = flag.Int64("minsize", 0, "skip files smaller than a size in bytes")
+	maxsize        = flag.Int64("maxsize", 0, "skip files larger than a size in bytes (0 for no limit)")
 	_              = flag.Bool("yaml", true, "YAML output format") // yaml is the default, need a flag so can overwrite config (see conf.go)
 	csvo           = flag.Bool("csv", false, "CSV output format")
 	jsono          = flag.Bool("json", false, "JSON output format")
@@ -57,16 +65,35 @@ var (
 	sig            = flag.String("sig", config.SignatureBase(), "set the signature file")
 	home           = flag.String("home", config.Home(), "override the default home directory")
 	serve          = flag.String("serve", "", "start siegfried server e.g. -serve localhost:5138")
-	multi          = flag.Int("multi", 1, "set number of parallel file ID processes")
+	multi          = flag.Int("multi", 1, "set number of parallel file ID processes (results are still written in walk order)")
 	archive        = flag.Bool("z", false, fmt.Sprintf("scan archive formats: (%s)", config.ListAllArcTypes()))
 	selectArchives = flag.String("zs", "", fmt.Sprintf("select archive formats to scan: (%s)", config.ListAllArcTypes()))
-	hashf          = flag.String("hash", "", "calculate file checksum with hash algorithm; options "+checksum.HashChoices)
+	zdepth         = flag.Int("zdepth", 32, "limit the nesting depth of archives within archives when scanning archive formats (0 for no limit)")
+	zratio         = flag.Float64("zratio", 0, "limit the ratio of bytes unpacked from an archive to the archive's size e.g. -zratio 100 (0 for no limit)")
+	zbytes         = flag.Int64("zbytes", 0, "limit the bytes unpacked from an archive (0 for no limit)")
+	zentries       = flag.Int("zentries", 0, "limit the number of entries unpacked from an archive (0 for no limit)")
+	hashf          = flag.String("hash", "", "calculate file checksums with one or more hash algorithms e.g. -hash md5,sha256; options "+checksum.HashChoices)
 	throttlef      = flag.Duration("throttle", 0, "set a time to wait between scanning files e.g. 50ms")
+	timeoutf       = flag.Duration("timeout", 0, "give up identifying a file after a duration, reporting any partial results e.g. -timeout 30s (0 for no limit)")
 	utcf           = flag.Bool("utc", false, "report file modified times in UTC, rather than local, TZ")
 	coe            = flag.Bool("coe", false, "continue on fatal errors during directory walks (this may result in directories being skipped)")
 	sym            = flag.Bool("sym", false, "follow symbolic links")
 	replay         = flag.Bool("replay", false, "replay one (or more) results files to change output or logging e.g. sf -replay -csv results.yaml")
-	list           = flag.Bool("f", false, "scan one (or more) lists of filenames e.g. sf -f myfiles.txt")
+	list           = flag.Bool("f", false, "scan one (or more) lists of filenames, each optionally followed by a tab-separated MIME type and name e.g. sf -f myfiles.txt")
+	list0          = flag.Bool("f0", false, "scan one (or more) lists of NUL-separated filenames, each optionally followed by a tab-separated MIME type and name e.g. find . -print0 | sf -f0 -")
+	sincef         = flag.String("since", "", "reuse results from a previous scan for files with the same size and modification time (and checksum, with -hash) e.g. sf -since old.yaml DIR")
+	policyf        = flag.String("policy", "", "check results against a policy file, setting the exit code if it isn't met e.g. sf -policy policy.yaml DIR")
+	dupesf         = flag.String("dupes", "", "write a CSV report of duplicate files (with the same checksum and size) e.g. sf -hash md5 -dupes dupes.csv DIR")
+	bagf           = flag.Bool("bag", false, "validate a BagIt bag and identify its payload e.g. sf -bag DIR or sf -z -bag bag.zip")
+	metaf          = flag.Bool("meta", false, "report filesystem metadata: birth, access and change times, mode, owner, group, inode and link count")
+	xattrsf        = flag.Bool("xattrs", false, "report extended attributes with -meta (Linux only)")
+	webmetaf       = flag.Bool("webmeta", false, "with -z, report WARC and ARC record metadata for the contents of web archives: record type and ID, HTTP status and headers, encodings and payload digests")
+	watchf         = flag.Bool("watch", false, "identify the files in a directory, then keep watching it, identifying new and modified files as they appear e.g. sf -watch -json DIR")
+	settlef        = flag.Duration("settle", 2*time.Second, "with -watch, wait until changed files haven't been written to for this long before identifying them")
+	pollf          = flag.Duration("poll", 0, "with -watch, poll for changes at this interval instead of using inotify (e.g. for network shares) e.g. -poll 10s")
+	deletesf       = flag.Bool("deletes", false, "with -watch, report files that are deleted")
+	verifyf        = flag.String("verify", "", "check files against the checksums in a manifest: a BagIt manifest, a sha256sum-style list or an sf results file e.g. sf -verify manifest-sha256.txt DIR")
+	resume         = flag.String("resume", "", "write results to a file, journaling completed paths so an interrupted scan can be resumed by repeating the command e.g. sf -resume results.yaml DIR")
 	name           = flag.String("name", "", "provide a filename when scanning a stream e.g. sf -name myfile.txt -")
 	conff          = flag.String("conf", "", "set the configuration file")
 	setconff       = flag.Bool("setconf", false, "record flags used with this command in configuration file")
@@ -107,7 +134,7 @@ func (we walkError) Error() string {
 	return fmt.Sprintf("[FATAL] file access error for %s: %v", we.path, we.err)
 }
 
-func setCtxPool(s *siegfried.Siegfried, wg *sync.WaitGroup, w writer.Writer, d, z bool, h checksum.HashTyp) {
+func setCtxPool(s *siegfried.Siegfried, wg *sync.WaitGroup, w writer.Writer, d, z bool, lim decompress.Limits, h checksum.HashTyps) {
 	ctxPool = &sync.Pool{
 		New: func() interface{} {
 			return &context{
@@ -116,7 +143,9 @@ func setCtxPool(s *siegfried.Siegfried, wg *sync.WaitGroup, w writer.Writer, d,
 				w:   w,
 				d:   d,
 				z:   z,
-				h:   checksum.MakeHash(h),
+				lim: lim,
+				ht:  h,
+				h:   checksum.MakeHashes(h),
 				res: make(chan results, 1),
 			}
 		},
@@ -130,7 +159,7 @@ func getCtx(path, mime string, mod time.Time, sz int64) *context {
 	if c.h != nil {
 		c.h.Reset()
 	}
-	c.path, c.mime, c.mod, c.sz = path, mime, mod, sz
+	c.path, c.mime, c.mod, c.sz, c.depth, c.parent, c.extra, c.prev, c.meta, c.lerr = path, mime, mod, sz, 0, "", nil, nil, nil, nil
 	return c
 }
 
@@ -140,35 +169,139 @@ type context struct {
 	w  writer.Writer
 	d  bool // droid
 	// opts
-	z bool
-	h hash.Hash
+	z   bool
+	lim decompress.Limits
+	ht  checksum.HashTyps
+	h   hash.Hash
 	// info
-	path string
-	mime string
-	mod  time.Time
-	sz   int64
+	path   string
+	mime   string
+	mod    time.Time
+	sz     int64
+	depth  int           // number of archives this file is nested within
+	parent string        // path of the archive this file is within, if any
+	extra  [][2]string   // extra fields reported by the archive this file is within (e.g. WARC record metadata)
+	prev   []reader.File // results from a previous scan that may be reused (see -since)
+	meta   [][2]string   // filesystem metadata (see -meta)
 	// results
-	res chan results
+	res  chan results
+	lerr error // a limit reached while unpacking an archive; set before its kids channel is closed
 }
 
 type results struct {
-	err error
-	cs  []byte
-	ids []core.Identification
+	err  error
+	cs   []byte
+	ids  []core.Identification
+	kids chan *context // the contents of an archive, nil if not an archive
 }
 
+// printer writes results in the order that contexts are sent to it, which is walk order.
+// With -multi, files are identified in parallel but printCtx blocks on each result in turn,
+// so the channel acts as a reorder buffer and output doesn't depend on which file finishes first.
 func printer(ctxts chan *context, lg *logger.Logger) {
 	for ctx := range ctxts {
-		lg.Progress(ctx.path)
-		// block on the results
-		res := <-ctx.res
-		lg.Error(ctx.path, res.err)
-		lg.IDs(ctx.path, res.ids)
-		if *utcf {
-			ctx.mod = ctx.mod.UTC()
-		}
-		// write the result
-		ctx.w.File(ctx.path, ctx.sz, ctx.mod.Format(time.RFC3339), res.cs, res.err, res.ids)
+		w := ctx.w
+		if rsm == nil {
+			printCtx(ctx, lg, true, nil)
+		} else {
+			// hold the waitgroup until the path has been journaled
+			path, wg := ctx.path, ctx.wg
+			wg.Add(1)
+			printCtx(ctx, lg, true, nil)
+			rsm.record(path)
+			wg.Done()
+		}
+		// with -watch, stream results once there are none waiting to be written
+		if wch != nil && len(ctxts) == 0 {
+			if f, ok := w.(writer.Flusher); ok {
+				f.Flush()
+			}
+		}
+	}
+}
+
+// printCtx writes a result, followed by the results for any archive contents.
+// Archive contents are printed in the order that they are unpacked, so output is deterministic
+// even when contents are identified in parallel. Top is false for archive contents.
+// If held isn't nil, writes are appended to it rather than made straight away.
+func printCtx(ctx *context, lg *logger.Logger, top bool, held *[]func()) {
+	lg.Progress(ctx.path)
+	// block on the results
+	res := <-ctx.res
+	if se, ok := res.err.(skipError); ok {
+		lg.Skip(ctx.path, se)
+		ctx.wg.Done()
+		ctxPool.Put(ctx)
+		return
+	}
+	// with -zratio, -zbytes or -zentries, the contents of an archive are held back until it has been unpacked,
+	// so that reaching a limit is reported in the archive's own result
+	var contents []func()
+	if res.kids != nil && unpackLimits(ctx.lim) {
+		for kid := range res.kids {
+			printCtx(kid, lg, false, &contents)
+		}
+		if ctx.lerr != nil {
+			if res.err == nil {
+				res.err = ctx.lerr
+			} else {
+				res.err = fmt.Errorf("%v; %v", res.err, ctx.lerr)
+			}
+		}
+		res.kids = nil
+	}
+	lg.Error(ctx.path, res.err)
+	lg.IDs(ctx.path, res.ids)
+	if top && ctx.sz >= 0 {
+		lg.Done(ctx.sz)
+	}
+	if *utcf {
+		ctx.mod = ctx.mod.UTC()
+	}
+	// check fixity with -verify
+	if top && vrf != nil && ctx.sz >= 0 {
+		ctx.extra = fixityExtra(ctx.extra, vrf.check(ctx.path, res.cs))
+	}
+	// with -bag, report fixity and paths relative to the bag
+	if bg != nil {
+		if status, ok := bg.fixity[bg.rel(ctx.path)]; ok && top {
+			ctx.extra = fixityExtra(ctx.extra, status)
+		}
+		ctx.path = bg.rel(ctx.path)
+	}
+	// report filesystem metadata with -meta
+	if ctx.meta != nil {
+		ctx.extra = metaExtra(ctx.extra, ctx.meta)
+	}
+	// check results with -policy (but not deletions reported with -watch)
+	if pol != nil && ctx.sz >= 0 && !isDeletion(ctx.extra) {
+		ctx.extra = policyExtra(ctx.extra, pol.verdict(res.err, res.ids))
+	}
+	// write the result
+	write := func() {
+		ctx.w.File(ctx.path, ctx.sz, ctx.mod.Format(time.RFC3339), res.cs, res.err, res.ids, ctx.extra...)
+		if dup != nil {
+			dup.Add(ctx.path, ctx.sz, res.cs)
+		}
+		for _, w := range contents {
+			w()
+		}
+	}
+	if held != nil {
+		*held = append(*held, func() {
+			write()
+			ctx.wg.Done()
+			ctxPool.Put(ctx)
+		})
+	} else {
+		write()
+	}
+	if res.kids != nil {
+		for kid := range res.kids {
+			printCtx(kid, lg, false, held)
+		}
+	}
+	if held == nil {
 		ctx.wg.Done()
 		ctxPool.Put(ctx) // return the context to the pool
 	}
@@ -176,96 +309,279 @@ func printer(ctxts chan *context, lg *logger.Logger) {
 
 // convenience function for printing files we haven't ID'ed (e.g. dirs or errors)
 func printFile(ctxs chan *context, ctx *context, err error) {
-	ctx.res <- results{err, nil, nil}
+	ctx.res <- results{err: err}
 	ctx.wg.Add(1)
 	ctxs <- ctx
 }
 
+// workers limits the number of files identified in parallel with -multi.
+// It is nil if files are identified sequentially.
+var workers chan struct{}
+
 // identify() defined in longpath.go and longpath_windows.go
 
-func readFile(ctx *context, ctxts chan *context, gf getFn) {
+func readFile(ctx *context, gf getFn) {
 	f, err := os.Open(ctx.path)
 	if err != nil {
 		f, err = retryOpen(ctx.path, err) // retry open in case is a windows long path error
 		if err != nil {
-			ctx.res <- results{err, nil, nil}
+			ctx.res <- results{err: err}
 			return
 		}
 	}
-	identifyRdr(f, ctx, ctxts, gf)
+	identifyRdr(f, ctx, gf)
 	f.Close()
 }
 
 func identifyFile(ctx *context, ctxts chan *context, gf getFn) {
+	if *metaf {
+		ctx.meta = getMeta(ctx.path)
+	}
 	wg := ctx.wg
 	wg.Add(1)
 	ctxts <- ctx
-	if *multi == 1 || ctx.z || config.Slow() || config.Debug() {
-		readFile(ctx, ctxts, gf)
+	if since != nil {
+		ctx.prev = since.lookup(ctx.path, ctx.sz, ctx.mod)
+		// without -hash, unchanged files don't need to be read
+		if ctx.prev != nil && ctx.h == nil {
+			reuse(ctx, gf)
+			return
+		}
+	}
+	if workers == nil {
+		readFile(ctx, gf)
 		return
 	}
+	workers <- struct{}{}
 	wg.Add(1)
 	go func() {
-		readFile(ctx, ctxts, gf)
+		readFile(ctx, gf)
+		<-workers
 		wg.Done()
 	}()
 }
 
-func identifyRdr(r io.Reader, ctx *context, ctxts chan *context, gf getFn) {
+func identifyRdr(r io.Reader, ctx *context, gf getFn) {
+	b, berr := ctx.s.Buffer(r)
+	if !identifyBuffer(b, berr, ctx, gf) {
+		ctx.s.Put(b)
+	}
+}
+
+// identifyBuffer sends the results for a buffer, and its contents if it is an archive.
+// It reports whether the identification (or that of an archive entry) stalled and was abandoned after a timeout, in which case
+// the buffer is still in use and must not be put back in the pool.
+func identifyBuffer(b *siegreader.Buffer, berr error, ctx *context, gf getFn) (stalled bool) {
 	s := ctx.s
-	b, berr := s.Buffer(r)
-	defer s.Put(b)
-	ids, err := s.IdentifyBuffer(b, berr, ctx.path, ctx.mime)
-	if ids == nil {
-		ctx.res <- results{err, nil, nil}
+	// with -since and -hash, unchanged files are checksummed before they are identified
+	var cs []byte
+	if ctx.prev != nil && berr == nil {
+		cs = checksumBuffer(b, ctx.h)
+		if bytes.Equal(cs, ctx.prev[0].Hash) {
+			reuse(ctx, gf)
+			return
+		}
+	}
+	ids, stalled, err := identifyTimeout(s, b, berr, ctx.path, ctx.mime)
+	// files that timed out aren't checksummed or decompressed, as that would mean reading them in full
+	if _, ok := err.(timeoutError); ok || ids == nil {
+		ctx.res <- results{err: err, ids: ids}
 		return
 	}
+	// some decompressed streams (e.g. bzip2) don't report their size in advance
+	if ctx.sz < 0 {
+		ctx.sz = b.SizeNow()
+	}
 	// calculate checksum
-	var cs []byte
 	if ctx.h != nil {
-		var i int64
-		l := ctx.h.BlockSize()
-		for ; ; i += int64(l) {
-			buf, _ := b.Slice(i, l)
-			if buf == nil {
-				break
+		if cs == nil {
+			cs = checksumBuffer(b, ctx.h)
+		}
+		if ctx.extra != nil {
+			for i, sum := range ctx.ht.Split(cs) {
+				ctx.extra = decompress.CheckDigest(ctx.extra, ctx.ht[i].String(), sum)
 			}
-			ctx.h.Write(buf)
 		}
-		cs = ctx.h.Sum(nil)
 	}
 	// decompress if an archive format
 	if !ctx.z {
-		ctx.res <- results{err, cs, ids}
+		ctx.res <- results{err, cs, ids, nil}
 		return
 	}
 	arc := decompress.IsArc(ids)
 	if arc == config.None {
-		ctx.res <- results{err, cs, ids}
+		ctx.res <- results{err, cs, ids, nil}
+		return
+	}
+	if lerr := ctx.lim.Nested(ctx.depth); lerr != nil {
+		ctx.res <- results{lerr, cs, ids, nil}
 		return
 	}
-	d, err := decompress.New(arc, b, ctx.path)
+	var d decompress.Decompressor
+	if ctx.parent == "" {
+		d, err = decompress.New(arc, b, ctx.path)
+	} else {
+		d, err = decompress.NewEntry(arc, b, ctx.path, ctx.parent)
+	}
 	if err != nil {
-		ctx.res <- results{fmt.Errorf("failed to decompress, got: %v", err), cs, ids}
+		ctx.res <- results{fmt.Errorf("failed to decompress, got: %v", err), cs, ids, nil}
 		return
 	}
-	// send the result
-	zpath := ctx.path
-	ctx.res <- results{err, cs, ids}
+	d = decompress.Limit(d, ctx.lim, ctx.sz)
+	// send the result; the printer won't recycle ctx until kids is closed
+	kids := make(chan *context, 8)
+	defer close(kids)
+	ctx.res <- results{err, cs, ids, kids}
 	// decompress and recurse
-	for err = d.Next(); err == nil; err = d.Next() {
+entries:
+	for err = d.Next(); err == nil || isEntryErr(err); err = d.Next() {
 		if ctx.d {
 			for _, v := range d.Dirs() {
-				printFile(ctxts, gf(v, "", time.Time{}, -1), nil)
+				printFile(kids, gf(v, "", time.Time{}, -1), nil)
 			}
 		}
+		if err != nil {
+			printFile(kids, gf(d.Path(), d.MIME(), d.Mod(), d.Size()), err)
+			continue
+		}
 		nctx := gf(d.Path(), d.MIME(), d.Mod(), d.Size())
+		nctx.depth, nctx.parent = ctx.depth+1, ctx.path
+		if f, ok := d.(decompress.Fielder); ok && *webmetaf {
+			nctx.extra = f.Fields()
+		}
 		nctx.wg.Add(1)
-		ctxts <- nctx
-		identifyRdr(d.Reader(), nctx, ctxts, gf)
+		kids <- nctx
+		select {
+		case workers <- struct{}{}: // identify in parallel if there is a spare worker
+			nb, nberr := s.Buffer(d.Reader())
+			if nberr == nil {
+				nb.SizeNow() // read the entry in full so the archive can advance to the next
+			}
+			go func() {
+				if !identifyBuffer(nb, nberr, nctx, gf) {
+					s.Put(nb)
+				}
+				<-workers
+			}()
+		default:
+			path := nctx.path
+			nb, nberr := s.Buffer(d.Reader())
+			if identifyBuffer(nb, nberr, nctx, gf) {
+				// the abandoned identification may still be reading the entry, so the archive can't be read any further
+				// and its buffer must not be put back in the pool either
+				err, stalled = fmt.Errorf("gave up after identification of %s stalled", path), true
+				break entries
+			}
+			s.Put(nb)
+		}
+	}
+	if le, ok := err.(decompress.LimitError); ok {
+		ctx.lerr = le // reported in the archive's own result once kids is closed
+	} else if err != io.EOF && err != nil {
+		printFile(kids, gf(decompress.Arcpath(ctx.path, ""), "", time.Time{}, 0), fmt.Errorf("error occurred during decompression: %v", err))
 	}
-	if err != io.EOF && err != nil {
-		printFile(ctxts, gf(decompress.Arcpath(zpath, ""), "", time.Time{}, 0), fmt.Errorf("error occurred during decompression: %v", err))
+	return
+}
+
+// timeoutGrace is how long matchers are given to stop once a timeout expires, before an identification is abandoned
+const timeoutGrace = time.Second
+
+// identifyTimeout identifies a buffer, giving up after the -timeout duration. When the timeout expires, the buffer's quit
+// channel is closed to cancel the matchers, and any partial results are returned with a timeoutError. If the identification
+// doesn't stop within the grace period (e.g. it is stalled reading from a hung network mount), it is abandoned.
+func identifyTimeout(s *siegfried.Siegfried, b *siegreader.Buffer, berr error, path, mime string) ([]core.Identification, bool, error) {
+	if *timeoutf <= 0 {
+		ids, err := s.IdentifyBuffer(b, berr, path, mime)
+		return ids, false, err
+	}
+	type idResult struct {
+		ids []core.Identification
+		err error
+	}
+	quit, done := make(chan struct{}), make(chan idResult, 1)
+	b.Quit = quit
+	go func() {
+		ids, err := s.IdentifyBuffer(b, berr, path, mime)
+		done <- idResult{ids, err}
+	}()
+	timer := time.NewTimer(*timeoutf)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return res.ids, false, res.err
+	case <-timer.C:
+	}
+	close(quit)
+	terr := timeoutError(*timeoutf)
+	timer.Reset(timeoutGrace)
+	select {
+	case res := <-done:
+		return res.ids, false, terr
+	case <-timer.C:
+		return nil, true, terr
+	}
+}
+
+type timeoutError time.Duration
+
+func (te timeoutError) Error() string {
+	return fmt.Sprintf("identification timed out after %v", time.Duration(te))
+}
+
+func checksumBuffer(b *siegreader.Buffer, h hash.Hash) []byte {
+	var i int64
+	l := h.BlockSize()
+	for ; ; i += int64(l) {
+		buf, _ := b.Slice(i, l)
+		if buf == nil {
+			break
+		}
+		h.Write(buf)
+	}
+	return h.Sum(nil)
+}
+
+// extraFields returns the names of the extra fields reported for archive contents and reused results.
+// Z is whether archives are unpacked, which can be set per request in server mode. Fields for features that are
+// set up after the server starts (-watch, -bag and -policy) are left out in server mode, as they don't apply.
+func extraFields(z bool) []string {
+	var ret []string
+	if *webmetaf && z && (config.WARC.Selected() || config.ARC.Selected()) {
+		ret = append(ret, decompress.WebFields...)
+	}
+	if *metaf {
+		ret = append(ret, metaFields...)
+		if *xattrsf {
+			ret = append(ret, xattrsField)
+		}
+	}
+	if *sincef != "" {
+		ret = append(ret, reusedField)
+	}
+	if wch != nil {
+		ret = append(ret, eventField)
+	}
+	if vrf != nil || bg != nil {
+		ret = append(ret, fixityField)
+	}
+	if pol != nil {
+		ret = append(ret, policyField)
+	}
+	return ret
+}
+
+// unpackLimits reports whether limits that are only reached while unpacking an archive (-zratio, -zbytes or -zentries) are set
+func unpackLimits(l decompress.Limits) bool {
+	return l.Ratio > 0 || l.Bytes > 0 || l.Entries > 0
+}
+
+// decompression limits set with the -zdepth, -zratio, -zbytes and -zentries flags
+func limits() decompress.Limits {
+	return decompress.Limits{
+		Depth:   *zdepth,
+		Ratio:   *zratio,
+		Bytes:   *zbytes,
+		Entries: *zentries,
 	}
 }
 
@@ -278,6 +594,12 @@ func openFile(path string) (*os.File, error) {
 
 var firstReplay sync.Once
 
+// entry errors (e.g. an encrypted entry) are reported, but don't stop decompression
+func isEntryErr(err error) bool {
+	_, ok := err.(decompress.EntryError)
+	return ok
+}
+
 func replayFile(path string, ctxts chan *context, w writer.Writer) error {
 	f, err := openFile(path)
 	if err != nil {
@@ -293,12 +615,19 @@ func replayFile(path string, ctxts chan *context, w writer.Writer) error {
 		return errors.New("[FATAL] DROID output is limited to signature files with a single PRONOM identifier")
 	}
 	firstReplay.Do(func() {
-		w.Head(hd.SignaturePath, hd.Scanned, hd.Created, hd.Version, hd.Identifiers, hd.Fields, hd.HashHeader)
+		if a, ok := w.(writer.Annotator); ok {
+			a.Annotate(hd.Annotations...)
+		}
+		if dup != nil {
+			dup.Duplicates = reader.NewDuplicates(hd.HashHeader)
+		}
+		w.Head(hd.SignaturePath, hd.Scanned, hd.Created, hd.Version, hd.Identifiers, hd.Fields, hd.HashHeader, hd.Extra...)
 	})
 	var rf reader.File
 	for rf, err = rdr.Next(); err == nil; rf, err = rdr.Next() {
 		ctx := getCtx(rf.Path, "", rf.Mod, rf.Size)
-		ctx.res <- results{rf.Err, rf.Hash, rf.IDs}
+		ctx.extra = rf.Extra
+		ctx.res <- results{rf.Err, rf.Hash, rf.IDs, nil}
 		ctx.wg.Add(1)
 		ctxts <- ctx
 	}
@@ -310,6 +639,9 @@ func replayFile(path string, ctxts chan *context, w writer.Writer) error {
 
 func main() {
 	flag.Parse()
+	if *list0 {
+		*list = true
+	}
 	// configure home
 	if *home != config.Home() {
 		config.SetHome(*home)
@@ -333,6 +665,9 @@ func main() {
 	if err := readconf(); err != nil {
 		log.Fatalf("[FATAL] error reading configuration file, %v", err)
 	}
+	if *xattrsf {
+		*metaf = true // after readconf, so -xattrs saved with -setconf implies -meta too
+	}
 	// configure signature
 	var usig string
 	if *sig != config.SignatureBase() {
@@ -349,9 +684,20 @@ func main() {
 		return
 	}
 	// handle -hash error
-	hashT := checksum.GetHash(*hashf)
-	if *hashf != "" && hashT < 0 {
-		log.Fatalf("[FATAL] invalid hash type; choose from %s", checksum.HashChoices)
+	hashT, herr := checksum.GetHashes(*hashf)
+	if herr != nil {
+		log.Fatalf("[FATAL] %v", herr)
+	}
+	// handle -verify
+	if *verifyf != "" {
+		if *replay || *serve != "" {
+			log.Fatalln("[FATAL] -verify can't be used with -replay or -serve")
+		}
+		vrf, herr = loadManifest(*verifyf)
+		if herr != nil {
+			log.Fatalf("[FATAL] failed to read manifest for -verify, %v", herr)
+		}
+		hashT = vrf.hashes(hashT)
 	}
 	// load and handle signature errors
 	var (
@@ -401,8 +747,8 @@ func main() {
 		return
 	}
 	// check -multi
-	if *multi > maxMulti || *multi < 1 || (*archive && *multi > 1) {
-		log.Println("[WARN] -multi must be > 0 and =< 1024. If -z, -multi must be 1. Resetting -multi to 1")
+	if *multi > maxMulti || *multi < 1 {
+		log.Println("[WARN] -multi must be > 0 and =< 1024. Resetting -multi to 1")
 		*multi = 1
 	}
 	// start logger
@@ -410,16 +756,50 @@ func main() {
 	if err != nil {
 		log.Fatalln(err)
 	}
+	if *progressfd > 0 {
+		f := os.NewFile(uintptr(*progressfd), "progressfd")
+		if _, err := f.Stat(); err != nil {
+			log.Fatalf("[FATAL] bad -progressfd %d, %v", *progressfd, err)
+		}
+		lg.ProgressJSON(f)
+	}
 	if config.Slow() || config.Debug() {
 		if *serve != "" || *fprflag {
 			log.Fatalln("[FATAL] debug and slow logging cannot be run in server mode")
 		}
 	}
+	if *multi > 1 && !config.Slow() && !config.Debug() {
+		workers = make(chan struct{}, *multi)
+	}
 	// start throttle
 	if *throttlef != 0 {
 		throttle = time.NewTicker(*throttlef)
 		defer throttle.Stop()
 	}
+	// handle -since
+	if *sincef != "" {
+		since, err = loadSince(*sincef, s.C, hashT.String(), unpacked())
+		if err != nil {
+			log.Fatalf("[FATAL] failed to read results file for -since, %v", err)
+		}
+	}
+	// handle -resume
+	out := os.Stdout
+	if *resume != "" {
+		if *replay || *droido || *serve != "" || lg.IsOut() {
+			log.Fatalln("[FATAL] -resume can't be used with -replay, -droid, -serve or logging to stdout")
+		}
+		rsm, err = newResumer(*resume)
+		if err != nil {
+			log.Fatalf("[FATAL] failed to open results file for -resume, %v", err)
+		}
+		if hd, ok := rsm.head(); ok && ((!hd.Created.IsZero() && !hd.Created.Equal(s.C.Truncate(time.Second))) || hd.HashHeader != hashT.String()) {
+			rsm.close(false)
+			os.Remove(tmpName(*resume))
+			log.Fatalf("[FATAL] can't resume %s: the signature file or -hash setting has changed (remove %s to start again)", *resume, journalName(*resume))
+		}
+		out = rsm.out
+	}
 	// start the printer
 	lenCtxts := *multi
 	if lenCtxts == 1 {
@@ -434,69 +814,120 @@ func main() {
 	case lg.IsOut():
 		w = writer.Null()
 	case *csvo:
-		w = writer.CSV(os.Stdout)
+		w = writer.CSV(out)
+	case *jsono && *watchf:
+		w = writer.JSONLines(out) // stream results as JSON lines
 	case *jsono:
-		w = writer.JSON(os.Stdout)
+		w = writer.JSON(out)
 	case *droido:
 		if !*replay && (len(s.Fields()) != 1 || len(s.Fields()[0]) < 7) {
 			close(ctxts)
 			log.Fatalln("[FATAL] DROID output is limited to signature files with a single PRONOM identifier")
 		}
 		decompress.SetDroid()
-		w = writer.Droid(os.Stdout)
+		w = writer.Droid(out)
 		d = true
 	default:
-		w = writer.YAML(os.Stdout)
+		w = writer.YAML(out)
 	}
 	// setup default waitgroup
 	wg := &sync.WaitGroup{}
 	// setup context pool
-	setCtxPool(s, wg, w, d, *archive, hashT)
+	setCtxPool(s, wg, w, d, *archive, limits(), hashT)
 	// handle -serve
 	if *serve != "" {
 		log.Printf("Starting server at %s. Use CTRL-C to quit.\n", *serve)
 		listen(*serve, s, ctxts)
 		return
 	}
+	flt := flagFilter()
 	// handle no file/directory argument
 	if flag.NArg() < 1 {
 		close(ctxts)
 		log.Fatalln("[FATAL] expecting one or more file or directory arguments (or '-' to scan stdin)")
 	}
+	// handle -policy
+	if *policyf != "" {
+		pol, err = loadPolicy(*policyf)
+		if err != nil {
+			close(ctxts)
+			log.Fatalf("[FATAL] failed to read policy, %v", err)
+		}
+	}
+	// handle -dupes
+	if *dupesf != "" {
+		if !*replay && len(hashT) == 0 {
+			close(ctxts)
+			log.Fatalln("[FATAL] -dupes requires -hash e.g. sf -hash md5 -dupes dupes.csv DIR")
+		}
+		dup, err = newDuplicates(*dupesf, hashT.String())
+		if err != nil {
+			close(ctxts)
+			log.Fatalf("[FATAL] failed to create report for -dupes, %v", err)
+		}
+	}
+	// handle -bag
+	if *bagf {
+		if *replay || *list || *verifyf != "" || *sincef != "" || *resume != "" || flag.NArg() != 1 {
+			close(ctxts)
+			log.Fatalln("[FATAL] -bag expects a single bag, and can't be used with -replay, -f, -verify, -since or -resume")
+		}
+		bg, err = newBag(flag.Arg(0), s)
+		if err != nil {
+			close(ctxts)
+			log.Fatalf("[FATAL] failed to read bag, %v", err)
+		}
+		if a, ok := w.(writer.Annotator); ok {
+			a.Annotate(bg.annotations()...)
+		}
+	}
+	// handle -watch
+	if *watchf {
+		if *replay || *list || *bagf || *verifyf != "" || *resume != "" || flag.NArg() != 1 {
+			close(ctxts)
+			log.Fatalln("[FATAL] -watch expects a single directory, and can't be used with -replay, -f, -bag, -verify or -resume")
+		}
+		wch, err = newWatcher(flag.Arg(0), flt, *settlef, *pollf, *deletesf)
+		if err != nil {
+			close(ctxts)
+			log.Fatalf("[FATAL] can't watch %s, %v", flag.Arg(0), err)
+		}
+	}
 	if !*replay {
-		w.Head(config.SignatureBase(), time.Now(), s.C, config.Version(), s.Identifiers(), s.Fields(), hashT.String())
+		scanned := time.Now()
+		if hd, ok := rsm.head(); ok && !hd.Scanned.IsZero() {
+			scanned = hd.Scanned // so a resumed scan looks like one run
+		}
+		if a, ok := w.(writer.Annotator); ok && *archive {
+			a.Annotate([2]string{unpackedNote, unpacked()})
+		}
+		w.Head(config.SignatureBase(), scanned, s.C, config.Version(), s.Identifiers(), s.Fields(), hashT.String(), extraFields(*archive)...)
+		if rsm != nil {
+			rsm.replay(w)
+		}
+	}
+	if lg.Tracking() {
+		if paths := countPaths(); paths != nil {
+			go countFiles(lg, paths, *nr, flt)
+		}
 	}
 	for _, v := range flag.Args() {
-		if *list {
-			f, err := openFile(v)
-			if err != nil {
-				break
-			}
-			scanner := bufio.NewScanner(f)
-			for scanner.Scan() {
-				if *replay {
-					err = replayFile(scanner.Text(), ctxts, w)
-					if err != nil {
-						break
-					}
-				} else {
-					err = identify(ctxts, scanner.Text(), "", *coe, *nr, d, getCtx)
-					if err != nil {
-						printFile(ctxts,
-							getCtx(scanner.Text(), "", time.Time{}, 0),
-							fmt.Errorf("failed to identify %s: %v", scanner.Text(), err))
-						err = nil
-					}
-				}
-			}
-			f.Close()
+		if wch != nil {
+			stop := make(chan os.Signal, 1)
+			signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+			err = wch.run(ctxts, d, stop)
+			signal.Stop(stop)
+		} else if bg != nil {
+			err = bg.identify(ctxts, d, flt, getCtx)
+		} else if *list {
+			err = scanList(v, ctxts, w, d, flt, getCtx)
 		} else if *replay {
 			err = replayFile(v, ctxts, w)
 		} else if v == "-" {
 			ctx := getCtx(*name, "", time.Time{}, 0)
 			ctx.wg.Add(1)
 			ctxts <- ctx
-			identifyRdr(os.Stdin, ctx, ctxts, getCtx)
+			identifyRdr(os.Stdin, ctx, getCtx)
 		} else {
 			// As a workaround for https://github.com/richardlehane/siegfried/issues/227 only do glob matching on Windows _after_ a direct match has been tried and the name contains characters that indicate a possible pattern
 			if runtime.GOOS == "windows" && strings.ContainsAny(v, "*?[\\") {
@@ -506,7 +937,7 @@ func main() {
 					matches, _ := filepath.Glob(v)
 					if matches != nil {
 						for _, match := range matches {
-							err = identify(ctxts, match, "", *coe, *nr, d, getCtx)
+							err = identify(ctxts, match, "", *coe, *nr, d, flt, getCtx)
 							if err != nil {
 								printFile(ctxts, getCtx(v, "", time.Time{}, 0), fmt.Errorf("failed to identify %s: %v", v, err))
 								err = nil
@@ -521,7 +952,7 @@ func main() {
 				}
 			}
 
-			err = identify(ctxts, v, "", *coe, *nr, d, getCtx)
+			err = identify(ctxts, v, "", *coe, *nr, d, flt, getCtx)
 		}
 
 		if err != nil {
@@ -530,11 +961,37 @@ func main() {
 	}
 	wg.Wait()
 	close(ctxts)
+	if vrf != nil {
+		vrf.missing(w)
+	}
+	if bg != nil {
+		bg.writeMissing(w)
+	}
 	w.Tail()
+	if rsm != nil {
+		if cerr := rsm.close(err == nil); cerr != nil && err == nil {
+			err = fmt.Errorf("[FATAL] failed to complete results file for -resume, %v", cerr)
+		}
+	}
+	if dup != nil {
+		if derr := dup.close(); derr != nil && err == nil {
+			err = fmt.Errorf("[FATAL] failed to write report for -dupes, %v", derr)
+		}
+	}
 	// log time elapsed and chart
 	lg.Close()
+	if vrf != nil {
+		vrf.summary()
+	}
+	if bg != nil {
+		bg.summary()
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
+	if pol != nil {
+		pol.summary()
+		os.Exit(pol.exitCode())
+	}
 	os.Exit(0)
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"archive/zip"
 	"bytes"
 	"errors"
 	"flag"
@@ -8,12 +9,15 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/richardlehane/siegfried"
+	"github.com/richardlehane/siegfried/internal/logger"
 	"github.com/richardlehane/siegfried/pkg/config"
 	"github.com/richardlehane/siegfried/pkg/pronom"
+	"github.com/richardlehane/siegfried/pkg/writer"
 )
 
 var (
@@ -263,6 +267,79 @@ func Test363(t *testing.T) {
 	}
 }
 
+// endless is a stream that never reaches EOF
+type endless struct{}
+
+func (endless) Read(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return len(p), nil
+}
+
+func TestTimeout(t *testing.T) {
+	if err := setup(); err != nil {
+		t.Fatal(err)
+	}
+	*timeoutf = 50 * time.Millisecond
+	defer func() { *timeoutf = 0 }()
+	b, berr := s.Buffer(endless{})
+	ids, stalled, err := identifyTimeout(s, b, berr, "test.txt", "")
+	if _, ok := err.(timeoutError); !ok || stalled {
+		t.Fatalf("expecting a timeout, got %v (stalled: %v)", err, stalled)
+	}
+	if len(ids) == 0 {
+		t.Error("expecting partial results")
+	}
+	s.Put(b)
+}
+
+// scanT scans a directory as sf does, identifying files in parallel if multi > 1, and returns the CSV output
+func scanT(t *testing.T, root string, multi int) string {
+	t.Helper()
+	lg, err := logger.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	workers = nil
+	if multi > 1 {
+		workers = make(chan struct{}, multi)
+		defer func() { workers = nil }()
+	}
+	buf := &bytes.Buffer{}
+	w := writer.CSV(buf)
+	w.Head("", time.Time{}, time.Time{}, [3]int{}, s.Identifiers(), s.Fields(), "")
+	wg := &sync.WaitGroup{}
+	setCtxPool(s, wg, w, false, *archive, limits(), nil)
+	ctxts := make(chan *context, multi)
+	printed := make(chan struct{})
+	go func() {
+		printer(ctxts, lg)
+		close(printed)
+	}()
+	err = identify(ctxts, root, "", true, false, false, flagFilter(), getCtx)
+	wg.Wait()
+	close(ctxts)
+	<-printed
+	w.Tail()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// TestMultiOrder checks that -multi results are written in walk order, so output is the same as for a single process
+func TestMultiOrder(t *testing.T) {
+	if err := setup(); err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(*testdata, "skeleton-suite", "fmt")
+	expect := scanT(t, root, 1)
+	for i := 0; i < 3; i++ {
+		if got := scanT(t, root, 16); got != expect {
+			t.Fatalf("-multi output differs from a single process on run %d", i+1)
+		}
+	}
+}
+
 // Benchmarks
 func benchidentify(ext string) {
 	setup()
@@ -361,3 +438,37 @@ func BenchmarkMulti(bench *testing.B) {
 		multiIdentifyT(s, dir)
 	}
 }
+
+// TestLimitResult checks that reaching a decompression limit is reported in the archive's own result
+func TestLimitResult(t *testing.T) {
+	if err := setup(); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	f, err := os.Create(filepath.Join(root, "test.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for i := 0; i < 5; i++ {
+		e, _ := zw.Create(fmt.Sprintf("%d.txt", i))
+		e.Write([]byte("hello world"))
+	}
+	zw.Close()
+	f.Close()
+	config.SetArchiveFilterPermissive("zip")
+	*archive, *zentries = true, 2
+	defer func() {
+		config.SetArchiveFilterPermissive("")
+		*archive, *zentries = false, 0
+	}()
+	for _, multi := range []int{1, 4} {
+		lines := strings.Split(strings.TrimSpace(scanT(t, root, multi)), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("expecting a header, the archive and two entries, got %v", lines)
+		}
+		if !strings.Contains(lines[1], "test.zip,") || !strings.Contains(lines[1], "entries exceed 2") {
+			t.Errorf("expecting the limit error in the archive's result, got %s", lines[1])
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
+	"github.com/richardlehane/siegfried/pkg/reader"
+)
+
+// With -since, results from a previous scan are reused for files that have the same size and modification time
+// (and the same checksum, if -hash is given). Reused results, and the results for the contents of reused archives,
+// are flagged with a "reused" field.
+
+// reusedField is the extra field that flags reused results
+const reusedField = "reused"
+
+// unpackedNote is the results header annotation that records the archive formats unpacked with -z or -zs
+const unpackedNote = "decompress"
+
+// unpacked returns the archive formats unpacked with -z or -zs, in order, or an empty string if archives aren't unpacked
+func unpacked() string {
+	if !*archive {
+		return ""
+	}
+	names := *selectArchives
+	if names == "" {
+		names = config.ListAllArcTypes()
+	}
+	arcs := strings.Split(names, ",")
+	for i, a := range arcs {
+		arcs[i] = strings.ToLower(strings.TrimSpace(a))
+	}
+	sort.Strings(arcs)
+	return strings.Join(arcs, ",")
+}
+
+// since is nil unless the -since flag is given
+var since previous
+
+// previous results, keyed by path. Each path's results are followed by the results for its contents if it is an archive.
+type previous map[string][]reader.File
+
+// loadSince reads a previous results file. If the file was produced with a different signature file or hash algorithm,
+// nil is returned so that all files are identified again. If different archive formats were unpacked (see unpacked),
+// results for archives are left out so that they are scanned again.
+func loadSince(path string, created time.Time, hh, arcs string) (previous, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rdr, err := reader.New(f, path)
+	if err != nil {
+		return nil, err
+	}
+	hd := rdr.Head()
+	switch {
+	case hd.Created.IsZero():
+		log.Printf("[WARN] %s doesn't record when its signature file was created, re-identifying all files", path)
+		return nil, nil
+	case !hd.Created.Equal(created.Truncate(time.Second)):
+		log.Printf("[WARN] the signature file has changed since %s was scanned, re-identifying all files", path)
+		return nil, nil
+	case hh != "" && hd.HashHeader != hh:
+		log.Printf("[WARN] %s doesn't have %s checksums, re-identifying all files", path, hh)
+		return nil, nil
+	}
+	var prevArcs string
+	for _, n := range hd.Annotations {
+		if n[0] == unpackedNote {
+			prevArcs = n[1]
+		}
+	}
+	rescan := prevArcs != arcs
+	if rescan {
+		log.Printf("[WARN] %s was scanned with different -z or -zs settings, re-identifying archives", path)
+	}
+	prev := make(previous)
+	var top string
+	var rf reader.File
+	for rf, err = rdr.Next(); err == nil; rf, err = rdr.Next() {
+		if hh == "" {
+			rf.Hash = nil
+		}
+		if top != "" && strings.HasPrefix(rf.Path, top+"#") {
+			prev[top] = append(prev[top], rf)
+			continue
+		}
+		top = rf.Path
+		prev[top] = []reader.File{rf}
+	}
+	if err != io.EOF {
+		return nil, err
+	}
+	if rescan {
+		for k, rfs := range prev {
+			if len(rfs) > 1 || isArchive(rfs[0].IDs) {
+				delete(prev, k)
+			}
+		}
+	}
+	return prev, nil
+}
+
+// isArchive reports whether previous results include an archive format that is unpacked with the current settings
+func isArchive(ids []core.Identification) bool {
+	for _, id := range ids {
+		if config.IsArchive(id.String()) != config.None {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the previous results for a file if its size and modification time are unchanged
+func (p previous) lookup(path string, sz int64, mod time.Time) []reader.File {
+	rfs, ok := p[path]
+	if !ok || rfs[0].Size != sz || !rfs[0].Mod.Equal(mod.Truncate(time.Second)) {
+		return nil
+	}
+	return rfs
+}
+
+// reuse sends the previous results for a file, and for its contents, to the printer
+func reuse(ctx *context, gf getFn) {
+	rf := ctx.prev[0]
+	ctx.extra = reusedExtra(rf.Extra)
+	if len(ctx.prev) == 1 {
+		ctx.res <- results{rf.Err, rf.Hash, rf.IDs, nil}
+		return
+	}
+	kids := make(chan *context, 8)
+	ctx.res <- results{rf.Err, rf.Hash, rf.IDs, kids}
+	for _, f := range ctx.prev[1:] {
+		nctx := gf(f.Path, "", f.Mod, f.Size)
+		nctx.extra = reusedExtra(f.Extra)
+		nctx.res <- results{f.Err, f.Hash, f.IDs, nil}
+		nctx.wg.Add(1)
+		kids <- nctx
+	}
+	close(kids)
+}
+
+// reusedExtra flags reused results, replacing the flag if the results were reused by the previous scan too
+func reusedExtra(extra [][2]string) [][2]string {
+	ret := make([][2]string, 0, len(extra)+1)
+	for _, e := range extra {
+		if e[0] != reusedField {
+			ret = append(ret, e)
+		}
+	}
+	return append(ret, [2]string{reusedField, "true"})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+	"github.com/richardlehane/siegfried/pkg/pronom"
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+func TestSince(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.yaml")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mod := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	fields := [][]string{{"namespace", "id", "format", "version", "mime", "class", "basis", "warning"}}
+	ids := []core.Identification{pronom.Identification{Namespace: "pronom", ID: "fmt/1"}}
+	w := writer.YAML(f)
+	w.(writer.Annotator).Annotate([2]string{unpackedNote, "zip"})
+	w.Head("default.sig", time.Now(), created, [3]int{1, 11, 1}, [][2]string{{"pronom", ""}}, fields, "md5", reusedField)
+	w.File("a", 1, mod.Format(time.RFC3339), []byte{1}, nil, ids, [2]string{reusedField, "true"})
+	w.File("b.zip", 2, mod.Format(time.RFC3339), []byte{2}, nil, ids)
+	w.File("b.zip#1", 1, "", []byte{3}, nil, ids)
+	w.Tail()
+	f.Close()
+	if prev, err := loadSince(path, created.Add(time.Hour), "md5", "zip"); prev != nil || err != nil {
+		t.Fatalf("expecting results to be ignored when the signature file has changed, got %v (%v)", prev, err)
+	}
+	prev, err := loadSince(path, created.Add(time.Millisecond), "md5", "zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rfs := prev.lookup("b.zip", 2, mod.Add(time.Millisecond)); len(rfs) != 2 || rfs[1].Path != "b.zip#1" {
+		t.Errorf("expecting b.zip and its contents, got %v", rfs)
+	}
+	if rfs := prev.lookup("a", 1, mod.Add(time.Second)); rfs != nil {
+		t.Errorf("expecting a changed file not to be reused, got %v", rfs)
+	}
+	rfs := prev.lookup("a", 1, mod)
+	if rfs == nil {
+		t.Fatal("expecting a to be reused")
+	}
+	if extra := reusedExtra(rfs[0].Extra); len(extra) != 1 || extra[0] != [2]string{reusedField, "true"} {
+		t.Errorf("expecting a single reused flag, got %v", extra)
+	}
+	// archives are scanned again if they weren't unpacked with the same settings
+	prev, err = loadSince(path, created, "md5", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rfs := prev.lookup("b.zip", 2, mod); rfs != nil {
+		t.Errorf("expecting b.zip not to be reused, got %v", rfs)
+	}
+	if rfs := prev.lookup("a", 1, mod); rfs == nil {
+		t.Error("expecting a to be reused")
+	}
+}
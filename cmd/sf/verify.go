@@ -0,0 +1,296 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/richardlehane/siegfried/internal/checksum"
+	"github.com/richardlehane/siegfried/pkg/reader"
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+// With -verify, files are checked against the checksums in a manifest while they are identified. The manifest can be a
+// BagIt manifest (manifest-<alg>.txt), a checksum list in the format written by sha256sum and similar tools, or an sf
+// results file with checksums. The manifest's hash algorithm is added to any given with -hash.
+//
+// The results for each file scanned (but not for the contents of archives) have a fixity field: "ok", "mismatch",
+// "not-in-manifest", or "unverified" if the file couldn't be checksummed (e.g. it couldn't be read or timed out).
+// Files in the manifest that don't exist are reported as "missing-on-disk" at the end of the results. A summary is logged.
+
+// fixityField is the extra field that reports the result of verification
+const fixityField = "fixity"
+
+const (
+	fixityOK            = "ok"
+	fixityMismatch      = "mismatch"
+	fixityMissing       = "missing-on-disk"
+	fixityNotInManifest = "not-in-manifest"
+	fixityUnverified    = "unverified"
+)
+
+// vrf is nil unless the -verify flag is given
+var vrf *verifier
+
+type verifier struct {
+	path   string
+	typs   checksum.HashTyps // the manifest's hash algorithms
+	idxs   []int             // the index of each of the manifest's hash algorithms in the checksums calculated by the scan
+	scan   checksum.HashTyps // the hash algorithms calculated by the scan
+	sums   map[string][]byte // manifest checksums, by absolute path
+	names  map[string]string // manifest paths, as reported
+	seen   map[string]bool
+	counts map[string]int
+}
+
+// loadManifest reads a manifest. sf results files are tried first, then checksum lists.
+func loadManifest(path string) (*verifier, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	v := &verifier{
+		path:   path,
+		sums:   make(map[string][]byte),
+		names:  make(map[string]string),
+		seen:   make(map[string]bool),
+		counts: make(map[string]int),
+	}
+	if rdr, rerr := reader.New(f, path); rerr == nil {
+		return v, v.readResults(rdr)
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return v, v.readSums(f)
+}
+
+func (v *verifier) add(name string, sum []byte) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		abs = name
+	}
+	v.sums[abs], v.names[abs] = sum, name
+}
+
+func (v *verifier) readResults(rdr reader.Reader) error {
+	var err error
+	v.typs, err = checksum.GetHashes(rdr.Head().HashHeader)
+	if err != nil {
+		return err
+	}
+	if len(v.typs) == 0 {
+		return fmt.Errorf("%s has no checksums", v.path)
+	}
+	var top string
+	var rf reader.File
+	for rf, err = rdr.Next(); err == nil; rf, err = rdr.Next() {
+		// skip archive contents
+		if top != "" && strings.HasPrefix(rf.Path, top+"#") {
+			continue
+		}
+		top = rf.Path
+		if rf.Hash != nil {
+			v.add(rf.Path, rf.Hash)
+		}
+	}
+	if err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// readSums reads lines of checksums and paths, separated by white space. Paths in BagIt manifests are relative to the bag
+// (the manifest's directory); others are relative to the current directory, as for sha256sum -c.
+// Binary mode markers (a "*" before the path) and BagIt's percent encoding of line breaks in paths are handled.
+func (v *verifier) readSums(r io.Reader) error {
+	bagit := strings.HasPrefix(filepath.Base(v.path), "manifest-")
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		idx := strings.IndexAny(line, " \t")
+		if idx < 0 {
+			return fmt.Errorf("bad line in %s, expecting a checksum and path: %s", v.path, line)
+		}
+		sum, err := hex.DecodeString(strings.ToLower(line[:idx]))
+		if err != nil {
+			return fmt.Errorf("bad checksum in %s: %s", v.path, line[:idx])
+		}
+		if v.typs == nil {
+			typ := manifestHash(v.path, len(sum))
+			if typ < 0 {
+				return fmt.Errorf("can't tell the hash algorithm used in %s; name it after the algorithm e.g. manifest-sha256.txt", v.path)
+			}
+			v.typs = checksum.HashTyps{typ}
+		}
+		p := strings.TrimPrefix(strings.TrimLeft(line[idx:], " \t"), "*")
+		p = filepath.FromSlash(p)
+		if bagit {
+			p = bagitPath.Replace(p)
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(filepath.Dir(v.path), p)
+			}
+		}
+		v.add(p, sum)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if v.typs == nil {
+		return fmt.Errorf("%s has no checksums", v.path)
+	}
+	return nil
+}
+
+// bagitPath decodes the percent encoding of line breaks and percent signs in the paths in BagIt manifests
+var bagitPath = strings.NewReplacer("%0A", "\n", "%0a", "\n", "%0D", "\r", "%0d", "\r", "%25", "%")
+
+// manifestHash returns the hash algorithm used in a checksum list. It is taken from the file name
+// (e.g. manifest-sha256.txt, SHA256SUMS, md5sum.txt or files.sha1), or guessed from the checksum length.
+func manifestHash(path string, sz int) checksum.HashTyp {
+	base := strings.ToLower(filepath.Base(path))
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	for _, name := range []string{
+		strings.TrimPrefix(stem, "manifest-"),
+		strings.TrimSuffix(strings.TrimSuffix(stem, "s"), "sum"),
+		strings.TrimPrefix(filepath.Ext(base), "."),
+	} {
+		if typ := checksum.GetHash(name); typ >= 0 {
+			return typ
+		}
+	}
+	switch sz {
+	case 16:
+		return checksum.GetHash("md5")
+	case 20:
+		return checksum.GetHash("sha1")
+	case 32:
+		return checksum.GetHash("sha256")
+	case 64:
+		return checksum.GetHash("sha512")
+	}
+	return -1
+}
+
+// hashes adds the manifest's hash algorithms to those given with -hash, and returns the algorithms to calculate
+func (v *verifier) hashes(typs checksum.HashTyps) checksum.HashTyps {
+	v.scan = append(checksum.HashTyps{}, typs...)
+	for _, t := range v.typs {
+		idx := -1
+		for i, s := range v.scan {
+			if s == t {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			idx = len(v.scan)
+			v.scan = append(v.scan, t)
+		}
+		v.idxs = append(v.idxs, idx)
+	}
+	return v.scan
+}
+
+// check returns the fixity of a scanned file, given the checksums calculated by the scan
+func (v *verifier) check(path string, cs []byte) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	want, ok := v.sums[abs]
+	status := fixityOK
+	switch {
+	case !ok:
+		status = fixityNotInManifest
+	case cs == nil:
+		status = fixityUnverified
+	default:
+		var got []byte
+		if sums := v.scan.Split(cs); sums != nil {
+			for _, idx := range v.idxs {
+				got = append(got, sums[idx]...)
+			}
+		}
+		if !bytes.Equal(got, want) {
+			status = fixityMismatch
+		}
+	}
+	v.seen[abs] = true
+	v.counts[status]++
+	return status
+}
+
+// replayed records the fixity of a file whose results were written by an interrupted scan (see -resume)
+func (v *verifier) replayed(path string, extra [][2]string) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	v.seen[abs] = true
+	for _, e := range extra {
+		if e[0] == fixityField {
+			v.counts[e[1]]++
+		}
+	}
+}
+
+// fixityExtra adds the fixity field to a file's extra fields
+func fixityExtra(extra [][2]string, status string) [][2]string {
+	ret := make([][2]string, 0, len(extra)+1)
+	for _, e := range extra {
+		if e[0] != fixityField {
+			ret = append(ret, e)
+		}
+	}
+	return append(ret, [2]string{fixityField, status})
+}
+
+// missing writes results for the files in the manifest that weren't scanned and don't exist
+func (v *verifier) missing(w writer.Writer) {
+	var paths []string
+	for abs := range v.sums {
+		if v.seen[abs] {
+			continue
+		}
+		if _, err := os.Lstat(abs); os.IsNotExist(err) {
+			paths = append(paths, abs)
+		}
+	}
+	sort.Strings(paths)
+	for _, abs := range paths {
+		w.File(v.names[abs], 0, "", nil, nil, nil, [2]string{fixityField, fixityMissing})
+		v.counts[fixityMissing]++
+	}
+}
+
+// summary logs the number of files with each fixity status
+func (v *verifier) summary() {
+	log.Printf("[VERIFY] %s: %d ok, %d mismatch, %d missing-on-disk, %d not-in-manifest, %d unverified",
+		v.path, v.counts[fixityOK], v.counts[fixityMismatch], v.counts[fixityMissing], v.counts[fixityNotInManifest], v.counts[fixityUnverified])
+}
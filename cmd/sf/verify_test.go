@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richardlehane/siegfried/internal/checksum"
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+func TestManifestHash(t *testing.T) {
+	for _, v := range []struct {
+		path string
+		sz   int
+		want string
+	}{
+		{"manifest-sha256.txt", 0, "sha256"},
+		{"tagmanifest-md5.txt", 16, "md5"},
+		{"SHA1SUMS", 0, "sha1"},
+		{"md5sum.txt", 0, "md5"},
+		{"files.sha512", 0, "sha512"},
+		{"checksums.txt", 20, "sha1"},
+	} {
+		if got := manifestHash(v.path, v.sz); got != checksum.GetHash(v.want) {
+			t.Errorf("%s: expecting %s, got %d", v.path, v.want, got)
+		}
+	}
+	if got := manifestHash("checksums.txt", 12); got >= 0 {
+		t.Errorf("expecting an unknown hash for a 12 byte checksum, got %d", got)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	bag := t.TempDir()
+	dir := filepath.Join(bag, "data")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	sum := func(s string) string {
+		h := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(h[:])
+	}
+	for name, content := range map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// paths in BagIt manifests are relative to the bag
+	manifest := sum("a") + "  data/a.txt\n" + sum("x") + "  data/b.txt\n" + sum("d") + " *data/d.txt\n"
+	path := filepath.Join(bag, "manifest-sha256.txt")
+	if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	v, err := loadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the manifest's algorithm follows those given with -hash
+	md5, _ := checksum.GetHashes("md5")
+	scan := v.hashes(md5)
+	if scan.String() != "md5,sha256" {
+		t.Fatalf("expecting md5,sha256, got %s", scan.String())
+	}
+	cs := func(s string) []byte {
+		h := checksum.MakeHashes(scan)
+		h.Write([]byte(s))
+		return h.Sum(nil)
+	}
+	for name, want := range map[string]string{"a.txt": fixityOK, "b.txt": fixityMismatch, "c.txt": fixityNotInManifest} {
+		if got := v.check(filepath.Join(dir, name), cs(strings.TrimSuffix(name, ".txt"))); got != want {
+			t.Errorf("%s: expecting %s, got %s", name, want, got)
+		}
+	}
+	buf := &bytes.Buffer{}
+	w := writer.CSV(buf)
+	w.Head("", time.Time{}, time.Time{}, [3]int{}, [][2]string{{"pronom", ""}}, [][]string{{"namespace", "id"}}, "", fixityField)
+	v.missing(w)
+	w.Tail()
+	if !strings.Contains(buf.String(), "d.txt") || !strings.Contains(buf.String(), fixityMissing) || strings.Contains(buf.String(), "a.txt") {
+		t.Errorf("expecting d.txt to be missing-on-disk, got %s", buf.String())
+	}
+	if v.counts[fixityMissing] != 1 || v.counts[fixityOK] != 1 {
+		t.Errorf("bad counts: %v", v.counts)
+	}
+}
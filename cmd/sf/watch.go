@@ -0,0 +1,326 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// With -watch, the files in a directory are identified and then sf keeps running, identifying new and modified files
+// as they appear (e.g. in an ingest drop folder) until it is interrupted. Changes are noticed with inotify on Linux,
+// or by walking the directory at the -poll interval (the default on other platforms, and useful for network shares
+// where inotify doesn't see changes made by other machines). A changed file is only identified once it has settled:
+// when its size and modification time haven't changed for the -settle duration, so that files still being written
+// aren't identified early. Results for new and modified files have an "event" field; with -deletes, files that are
+// removed are reported too, with the size and modification time they had when last identified. Results are flushed
+// as they are written so that they can be streamed, and JSON output is written as JSON lines (see writer.JSONLines).
+
+// eventField is the extra field that reports why a file was identified while watching
+const eventField = "event"
+
+const (
+	eventNew      = "new"
+	eventModified = "modified"
+	eventDeleted  = "deleted"
+)
+
+// defaultPoll is the interval at which directories are polled for changes if inotify isn't available
+const defaultPoll = 2 * time.Second
+
+// wch is nil unless the -watch flag is given
+var wch *watcher
+
+// notifier reports paths that have changed in the directories it watches. An empty path means changes may have been
+// missed (e.g. the inotify queue overflowed), so the whole directory should be checked. newNotifier is defined in
+// watch_linux.go and watch_other.go.
+type notifier interface {
+	add(dir string) error // watch a directory's entries (not recursively)
+	changes() <-chan string
+	close() error
+}
+
+// fileState is used to tell whether a file has changed
+type fileState struct {
+	size int64
+	mod  time.Time
+}
+
+func stateOf(info os.FileInfo) fileState {
+	return fileState{info.Size(), info.ModTime()}
+}
+
+// pending is a change that is waiting to settle
+type pending struct {
+	seen  time.Time // when the change was last seen
+	state fileState
+	ok    bool // false if the path didn't exist
+}
+
+type watcher struct {
+	root    string
+	flt     *filter
+	wk      *walker
+	settle  time.Duration
+	poll    time.Duration // 0 if changes are reported by a notifier
+	deletes bool
+	ntf     notifier
+	out     os.FileInfo          // results file, which isn't identified if it is in the directory watched
+	known   map[string]fileState // files that have been identified
+	pending map[string]pending
+}
+
+func newWatcher(root string, flt *filter, settle, poll time.Duration, deletes bool) (*watcher, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s isn't a directory", root)
+	}
+	w := &watcher{
+		root:    root,
+		flt:     flt,
+		wk:      flt.walker(root),
+		settle:  settle,
+		poll:    poll,
+		deletes: deletes,
+		known:   make(map[string]fileState),
+		pending: make(map[string]pending),
+	}
+	if out, err := os.Stdout.Stat(); err == nil && out.Mode().IsRegular() {
+		w.out = out
+	}
+	if poll > 0 {
+		return w, nil
+	}
+	w.ntf, err = newNotifier()
+	if err != nil {
+		log.Printf("[WARN] can't watch %s for changes (%v), polling every %s instead", root, err, defaultPoll)
+	}
+	if w.ntf == nil {
+		w.poll = defaultPoll
+	}
+	return w, nil
+}
+
+// run identifies the files in the directory, then identifies files as they change until stop receives
+func (w *watcher) run(ctxts chan *context, droid bool, stop <-chan os.Signal) error {
+	if w.ntf != nil {
+		defer w.ntf.close()
+	}
+	// record the files before they are identified, so that changes made during the first scan are picked up
+	w.walk(w.root, func(p string, info os.FileInfo) {
+		w.known[p] = stateOf(info)
+	})
+	if err := identify(ctxts, w.root, "", *coe, *nr, droid, w.flt, getCtx); err != nil {
+		return err
+	}
+	log.Printf("Watching %s for changes. Use CTRL-C to quit.\n", w.root)
+	var changes <-chan string
+	if w.ntf != nil {
+		changes = w.ntf.changes()
+	}
+	var polls <-chan time.Time
+	if w.poll > 0 {
+		pt := time.NewTicker(w.poll)
+		defer pt.Stop()
+		polls = pt.C
+	}
+	interval := w.settle / 4
+	if interval < 50*time.Millisecond {
+		interval = 50 * time.Millisecond
+	}
+	st := time.NewTicker(interval)
+	defer st.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case p := <-changes:
+			if p == "" {
+				w.check()
+				continue
+			}
+			w.touch(p)
+		case <-polls:
+			w.check()
+		case <-st.C:
+			w.settled(ctxts, droid)
+		}
+	}
+}
+
+// walk calls fn for the regular files in a directory that pass the filters, watching the directory and any
+// sub-directories for changes
+func (w *watcher) walk(dir string, fn func(string, os.FileInfo)) {
+	filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if reason := w.wk.skip(p, info); reason != "" {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			if *nr && p != w.root {
+				return filepath.SkipDir
+			}
+			if w.ntf != nil {
+				if err := w.ntf.add(p); err != nil {
+					log.Printf("[WARN] can't watch %s for changes, %v", p, err)
+				}
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			fn(p, info)
+		}
+		return nil
+	})
+}
+
+// check compares the files in the directory with those identified, and marks any differences as pending
+func (w *watcher) check() {
+	seen := make(map[string]bool)
+	w.walk(w.root, func(p string, info os.FileInfo) {
+		seen[p] = true
+		if st, ok := w.known[p]; !ok || st != stateOf(info) {
+			w.touch(p)
+		}
+	})
+	for p := range w.known {
+		if !seen[p] {
+			w.touch(p)
+		}
+	}
+}
+
+// touch marks a path as changed. It won't be identified until it has settled.
+func (w *watcher) touch(p string) {
+	var pd pending
+	if info, err := os.Lstat(p); err == nil {
+		pd.state, pd.ok = stateOf(info), true
+	}
+	if prev, ok := w.pending[p]; ok && prev.state == pd.state && prev.ok == pd.ok {
+		return
+	}
+	pd.seen = time.Now()
+	w.pending[p] = pd
+}
+
+// settled identifies (or reports the deletion of) any pending paths that haven't changed for the settle duration
+func (w *watcher) settled(ctxts chan *context, droid bool) {
+	var ready []string
+	for p, pd := range w.pending {
+		info, err := os.Lstat(p)
+		var st fileState
+		if err == nil {
+			st = stateOf(info)
+		}
+		if st != pd.state || (err == nil) != pd.ok {
+			w.pending[p] = pending{time.Now(), st, err == nil}
+			continue
+		}
+		if time.Since(pd.seen) >= w.settle {
+			ready = append(ready, p)
+		}
+	}
+	sort.Strings(ready)
+	for _, p := range ready {
+		delete(w.pending, p)
+		w.changed(ctxts, droid, p)
+	}
+}
+
+// changed handles a path that has settled
+func (w *watcher) changed(ctxts chan *context, droid bool, p string) {
+	info, err := os.Lstat(p)
+	if err != nil {
+		w.removed(ctxts, p)
+		return
+	}
+	if w.wk.skip(p, info) != "" {
+		return
+	}
+	// a new directory: watch it and wait for its files to settle
+	if info.IsDir() {
+		if *nr && p != w.root {
+			return
+		}
+		w.walk(p, func(fp string, fi os.FileInfo) {
+			if st, ok := w.known[fp]; !ok || st != stateOf(fi) {
+				w.touch(fp)
+			}
+		})
+		return
+	}
+	if !info.Mode().IsRegular() || (w.out != nil && os.SameFile(w.out, info)) {
+		return
+	}
+	event := eventNew
+	if st, ok := w.known[p]; ok {
+		if st == stateOf(info) {
+			return
+		}
+		event = eventModified
+	}
+	w.known[p] = stateOf(info)
+	gf := func(path, mime string, mod time.Time, sz int64) *context {
+		ctx := getCtx(path, mime, mod, sz)
+		if path == p { // not the contents of archives
+			ctx.extra = [][2]string{{eventField, event}}
+		}
+		return ctx
+	}
+	identify(ctxts, p, "", true, *nr, droid, w.flt, gf)
+}
+
+// removed forgets a deleted file, or the files within a deleted directory, reporting them with -deletes
+func (w *watcher) removed(ctxts chan *context, p string) {
+	var gone []string
+	for fp := range w.known {
+		if fp == p || strings.HasPrefix(fp, p+string(filepath.Separator)) {
+			gone = append(gone, fp)
+		}
+	}
+	sort.Strings(gone)
+	for _, fp := range gone {
+		st := w.known[fp]
+		delete(w.known, fp)
+		if w.deletes {
+			ctx := getCtx(fp, "", st.mod, st.size)
+			ctx.extra = [][2]string{{eventField, eventDeleted}}
+			printFile(ctxts, ctx, nil)
+		}
+	}
+}
+
+// isDeletion reports whether a result is for a file deleted while watching
+func isDeletion(extra [][2]string) bool {
+	for _, e := range extra {
+		if e[0] == eventField {
+			return e[1] == eventDeleted
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_CREATE | unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_ATTRIB |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_DELETE
+
+type inotify struct {
+	fd   int // not f.Fd(), which would set the file to blocking mode
+	f    *os.File
+	mu   sync.Mutex
+	dirs map[int32]string // watch descriptors
+	ch   chan string
+	done chan struct{}
+}
+
+func newNotifier() (notifier, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	n := &inotify{
+		fd:   fd,
+		f:    os.NewFile(uintptr(fd), "inotify"), // non-blocking, so reads are interrupted by close
+		dirs: make(map[int32]string),
+		ch:   make(chan string, 256),
+		done: make(chan struct{}),
+	}
+	go n.read()
+	return n, nil
+}
+
+func (n *inotify) add(dir string) error {
+	wd, err := unix.InotifyAddWatch(n.fd, dir, inotifyMask)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	n.dirs[int32(wd)] = dir
+	n.mu.Unlock()
+	return nil
+}
+
+func (n *inotify) changes() <-chan string { return n.ch }
+
+func (n *inotify) close() error {
+	close(n.done)
+	return n.f.Close()
+}
+
+func (n *inotify) send(p string) bool {
+	select {
+	case n.ch <- p:
+		return true
+	case <-n.done:
+		return false
+	}
+}
+
+func (n *inotify) read() {
+	buf := make([]byte, 64*1024)
+	for {
+		l, err := n.f.Read(buf)
+		if err != nil {
+			return
+		}
+		for off := 0; off+unix.SizeofInotifyEvent <= l; {
+			ev := (*unix.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			name := strings.TrimRight(string(buf[off+unix.SizeofInotifyEvent:off+unix.SizeofInotifyEvent+int(ev.Len)]), "\x00")
+			off += unix.SizeofInotifyEvent + int(ev.Len)
+			if ev.Mask&unix.IN_Q_OVERFLOW != 0 {
+				if !n.send("") {
+					return
+				}
+				continue
+			}
+			n.mu.Lock()
+			dir, ok := n.dirs[ev.Wd]
+			if ev.Mask&unix.IN_IGNORED != 0 {
+				delete(n.dirs, ev.Wd)
+			}
+			n.mu.Unlock()
+			if ok && name != "" && !n.send(filepath.Join(dir, name)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/richardlehane/siegfried/internal/logger"
+	"github.com/richardlehane/siegfried/pkg/reader"
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+func TestWatch(t *testing.T) {
+	if err := setup(); err != nil {
+		t.Fatal(err)
+	}
+	lg, err := logger.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, poll := range []time.Duration{0, 20 * time.Millisecond} {
+		dir := t.TempDir()
+		write := func(name, content string) {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		write("a.txt", "hello")
+		write("c.txt", "goodbye")
+		buf := &bytes.Buffer{}
+		w := writer.JSONLines(buf)
+		w.Head("", time.Time{}, time.Time{}, [3]int{}, s.Identifiers(), s.Fields(), "", eventField)
+		wg := &sync.WaitGroup{}
+		setCtxPool(s, wg, w, false, false, limits(), nil)
+		ctxts := make(chan *context, 1)
+		printed := make(chan struct{})
+		go func() {
+			printer(ctxts, lg)
+			close(printed)
+		}()
+		wch, err = newWatcher(dir, flagFilter(), 50*time.Millisecond, poll, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stop, done := make(chan os.Signal), make(chan error)
+		go func() { done <- wch.run(ctxts, false, stop) }()
+		time.Sleep(200 * time.Millisecond)
+		write("a.txt", "hello again")
+		write("b.txt", "new")
+		os.Remove(filepath.Join(dir, "c.txt"))
+		time.Sleep(time.Second)
+		stop <- os.Interrupt
+		err = <-done
+		wg.Wait()
+		close(ctxts)
+		<-printed
+		w.Tail()
+		wch = nil
+		if err != nil {
+			t.Fatal(err)
+		}
+		rdr, err := reader.New(buf, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for f, err := rdr.Next(); err == nil; f, err = rdr.Next() {
+			event := "existing"
+			for _, e := range f.Extra {
+				if e[0] == eventField {
+					event = e[1]
+				}
+			}
+			got = append(got, filepath.Base(f.Path)+" "+event)
+		}
+		expect := "a.txt existing,c.txt existing,a.txt modified,b.txt new,c.txt deleted"
+		if strings.Join(got, ",") != expect {
+			t.Errorf("polling every %s: expecting %s, got %s\n%s", poll, expect, strings.Join(got, ","), buf)
+		}
+	}
+}
@@ -3,17 +3,23 @@ module github.com/richardlehane/siegfried
 go 1.18
 
 require (
+	github.com/cespare/xxhash/v2 v2.3.0
+	github.com/klauspost/compress v1.16.7
 	github.com/richardlehane/characterize v1.0.0
 	github.com/richardlehane/match v1.0.5
 	github.com/richardlehane/mscfb v1.0.4
 	github.com/richardlehane/webarchive v1.0.3
 	github.com/richardlehane/xmldetect v1.0.2
 	github.com/ross-spencer/wikiprov v0.2.0
+	github.com/ulikunitz/xz v0.5.12
+	golang.org/x/crypto v0.24.0
 	golang.org/x/image v0.18.0
 	golang.org/x/sys v0.22.0
+	lukechampine.com/blake3 v1.3.0
 )
 
 require (
+	github.com/klauspost/cpuid/v2 v2.0.12 // indirect
 	github.com/richardlehane/msoleps v1.0.3 // indirect
 	github.com/ross-spencer/spargo v0.4.1 // indirect
 	golang.org/x/text v0.16.0 // indirect
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames/tests"
 	"github.com/richardlehane/siegfried/internal/persist"
@@ -82,3 +83,38 @@ func TestMatch(t *testing.T) {
 		t.Errorf("Missing result, got: %v, expecting:%v\n", results, bm)
 	}
 }
+
+// slowReader is an endless stream
+type slowReader struct{}
+
+func (slowReader) Read(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return len(p), nil
+}
+
+func TestQuit(t *testing.T) {
+	bm, _, err := Add(nil, SignatureSet(tests.TestSignatures), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	bufs := siegreader.New()
+	buf, err := bufs.Get(io.MultiReader(bytes.NewReader(TestSample1), slowReader{}))
+	if err != nil && err != io.EOF {
+		t.Error(err)
+	}
+	quit := make(chan struct{})
+	buf.Quit = quit
+	res, _ := bm.Identify("", buf)
+	time.AfterFunc(10*time.Millisecond, func() { close(quit) })
+	done := make(chan struct{})
+	go func() {
+		for range res {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expecting the matcher to stop when the buffer's quit channel is closed")
+	}
+}
@@ -16,6 +16,7 @@ package bytematcher
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/richardlehane/match/dwac"
 	"github.com/richardlehane/siegfried/internal/siegreader"
@@ -25,6 +26,20 @@ import (
 
 // identify function - brings a new matcher into existence
 func (b *Matcher) identify(buf *siegreader.Buffer, quit chan struct{}, r chan core.Result, hints ...core.Hint) {
+	// the matcher quits when it has a result, or when the buffer's own quit channel is closed (e.g. on a timeout)
+	var once sync.Once
+	stop := func() { once.Do(func() { close(quit) }) }
+	if ext := buf.Quit; ext != nil {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ext:
+				stop()
+			case <-done:
+			}
+		}()
+	}
 	buf.Quit = quit
 	waitSet := b.priorities.WaitSet(hints...)
 	maxBOF, maxEOF := b.maxBOF, b.maxEOF
@@ -40,7 +55,7 @@ func (b *Matcher) identify(buf *siegreader.Buffer, quit chan struct{}, r chan co
 			maxBOF, maxEOF = waitSet.MaxOffsets()
 		}
 	}
-	incoming, resume := b.scorer(buf, waitSet, quit, r)
+	incoming, resume := b.scorer(buf, waitSet, stop, r)
 	rdr := siegreader.LimitReaderFrom(buf, maxBOF)
 	// First test BOF frameset
 	bfchan := b.bofFrames.index(buf, false, quit)
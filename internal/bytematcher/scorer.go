@@ -189,7 +189,7 @@ func (r result) Basis() string {
 	return r.basis
 }
 
-func (b *Matcher) scorer(buf *siegreader.Buffer, waitSet *priority.WaitSet, q chan struct{}, r chan<- core.Result) (chan<- strike, <-chan []keyFrameID) {
+func (b *Matcher) scorer(buf *siegreader.Buffer, waitSet *priority.WaitSet, stop func(), r chan<- core.Result) (chan<- strike, <-chan []keyFrameID) {
 	incoming := make(chan strike)
 	resume := make(chan []keyFrameID)
 	hits := make(map[int]*hitItem)
@@ -200,7 +200,7 @@ func (b *Matcher) scorer(buf *siegreader.Buffer, waitSet *priority.WaitSet, q ch
 
 	var quitting bool
 	quit := func() {
-		close(q)
+		stop()
 		close(resume)
 		quitting = true
 	}
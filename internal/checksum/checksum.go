@@ -19,11 +19,19 @@ import (
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"fmt"
 	"hash"
 	"hash/crc32"
+	"io"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+	"lukechampine.com/blake3"
 )
 
-const HashChoices = "'md5', 'sha1', 'sha256', 'sha512', 'crc'"
+const HashChoices = "'md5', 'sha1', 'sha256', 'sha512', 'sha3-256', 'blake2b-512', 'blake3', 'xxh64', 'crc'"
 
 type HashTyp int
 
@@ -33,6 +41,10 @@ const (
 	sha256Hash
 	sha512Hash
 	crcHash
+	sha3Hash    // SHA3-256
+	blake2bHash // BLAKE2b-512
+	blake3Hash  // BLAKE3 with a 256 bit digest
+	xxh64Hash   // xxHash (XXH64), which is fast but not cryptographic
 )
 
 func GetHash(typ string) HashTyp {
@@ -48,6 +60,14 @@ func GetHash(typ string) HashTyp {
 		return sha512Hash
 	case "crc", "CRC":
 		return crcHash
+	case "sha3-256", "SHA3-256", "sha3", "SHA3":
+		return sha3Hash
+	case "blake2b-512", "BLAKE2b-512", "BLAKE2B-512", "blake2b", "BLAKE2b", "BLAKE2B":
+		return blake2bHash
+	case "blake3", "BLAKE3":
+		return blake3Hash
+	case "xxh64", "XXH64", "xxhash", "XXHASH":
+		return xxh64Hash
 	}
 	return -1
 }
@@ -64,6 +84,15 @@ func MakeHash(typ HashTyp) hash.Hash {
 		return sha512.New()
 	case crcHash:
 		return crc32.NewIEEE()
+	case sha3Hash:
+		return sha3.New256()
+	case blake2bHash:
+		h, _ := blake2b.New512(nil) // only errors if the key is too long
+		return h
+	case blake3Hash:
+		return blake3.New(32, nil)
+	case xxh64Hash:
+		return xxhash.New()
 	}
 	return nil
 }
@@ -80,6 +109,133 @@ func (typ HashTyp) String() string {
 		return "sha512"
 	case crcHash:
 		return "crc"
+	case sha3Hash:
+		return "sha3-256"
+	case blake2bHash:
+		return "blake2b-512"
+	case blake3Hash:
+		return "blake3"
+	case xxh64Hash:
+		return "xxh64"
 	}
 	return ""
 }
+
+// HashTyps is a list of hash algorithms that are calculated in a single pass
+type HashTyps []HashTyp
+
+// GetHashes parses a comma separated list of hash algorithms e.g. "md5,sha256".
+// Returns nil if the list is empty, and an error if any algorithm is invalid.
+func GetHashes(typs string) (HashTyps, error) {
+	var ret HashTyps
+	for _, v := range strings.Split(typs, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" || v == "false" {
+			continue
+		}
+		h := GetHash(v)
+		if h < 0 {
+			return nil, fmt.Errorf("invalid hash type %s; choose from %s", v, HashChoices)
+		}
+		if !contains(ret, h) {
+			ret = append(ret, h)
+		}
+	}
+	return ret, nil
+}
+
+func contains(typs HashTyps, typ HashTyp) bool {
+	for _, v := range typs {
+		if v == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// MakeHashes returns a hash that calculates all of the listed hashes. Its sum is their sums concatenated in order,
+// which can be separated again with Split. Returns nil if there are no hash algorithms.
+func MakeHashes(typs HashTyps) hash.Hash {
+	switch len(typs) {
+	case 0:
+		return nil
+	case 1:
+		return MakeHash(typs[0])
+	}
+	m := multiHash{hashes: make([]hash.Hash, len(typs))}
+	ws := make([]io.Writer, len(typs))
+	for i, t := range typs {
+		m.hashes[i] = MakeHash(t)
+		ws[i] = m.hashes[i]
+	}
+	m.Writer = io.MultiWriter(ws...)
+	return m
+}
+
+// String returns the hash header: the comma separated names of the hash algorithms
+func (typs HashTyps) String() string {
+	strs := make([]string, len(typs))
+	for i, t := range typs {
+		strs[i] = t.String()
+	}
+	return strings.Join(strs, ",")
+}
+
+// Split separates a sum calculated with MakeHashes into the sums for each hash algorithm.
+// Returns nil if the sum is the wrong length.
+func (typs HashTyps) Split(sum []byte) [][]byte {
+	if len(typs) == 1 {
+		return [][]byte{sum}
+	}
+	ret := make([][]byte, len(typs))
+	var idx int
+	for i, t := range typs {
+		sz := MakeHash(t).Size()
+		if idx+sz > len(sum) {
+			return nil
+		}
+		ret[i] = sum[idx : idx+sz]
+		idx += sz
+	}
+	if idx != len(sum) {
+		return nil
+	}
+	return ret
+}
+
+type multiHash struct {
+	io.Writer
+	hashes []hash.Hash
+}
+
+func (m multiHash) Sum(b []byte) []byte {
+	for _, h := range m.hashes {
+		b = h.Sum(b)
+	}
+	return b
+}
+
+func (m multiHash) Reset() {
+	for _, h := range m.hashes {
+		h.Reset()
+	}
+}
+
+func (m multiHash) Size() int {
+	var sz int
+	for _, h := range m.hashes {
+		sz += h.Size()
+	}
+	return sz
+}
+
+// BlockSize is the largest block size of the hashes
+func (m multiHash) BlockSize() int {
+	var bs int
+	for _, h := range m.hashes {
+		if h.BlockSize() > bs {
+			bs = h.BlockSize()
+		}
+	}
+	return bs
+}
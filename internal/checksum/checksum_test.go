@@ -0,0 +1,48 @@
+package checksum
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestHashes(t *testing.T) {
+	// checksums of the empty string
+	for _, v := range []struct {
+		typ    string
+		expect string
+	}{
+		{"md5", "d41d8cd98f00b204e9800998ecf8427e"},
+		{"sha3-256", "a7ffc6f8bf1ed76651c14756a061d662f580ff4de43b49fa82d80a4b80f8434a"},
+		{"blake2b-512", "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+		{"blake3", "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"},
+		{"xxh64", "ef46db3751d8e999"},
+	} {
+		typ := GetHash(v.typ)
+		if typ < 0 || typ.String() != v.typ {
+			t.Fatalf("%s: bad hash type %d", v.typ, typ)
+		}
+		if got := hex.EncodeToString(MakeHash(typ).Sum(nil)); got != v.expect {
+			t.Errorf("%s: expecting %s, got %s", v.typ, v.expect, got)
+		}
+	}
+}
+
+func TestMultiHash(t *testing.T) {
+	typs, err := GetHashes("md5, xxh64,md5")
+	if err != nil || typs.String() != "md5,xxh64" {
+		t.Fatalf("expecting md5,xxh64, got %s (%v)", typs, err)
+	}
+	h := MakeHashes(typs)
+	h.Write([]byte("hello"))
+	sums := typs.Split(h.Sum(nil))
+	for i, typ := range typs {
+		single := MakeHash(typ)
+		single.Write([]byte("hello"))
+		if hex.EncodeToString(sums[i]) != hex.EncodeToString(single.Sum(nil)) {
+			t.Errorf("%s: expecting %x, got %x", typ, single.Sum(nil), sums[i])
+		}
+	}
+	if _, err = GetHashes("md5,bogus"); err == nil {
+		t.Error("expecting an error for an invalid hash type")
+	}
+}
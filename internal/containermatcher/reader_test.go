@@ -1,6 +1,7 @@
 package containermatcher
 
 import (
+	"archive/zip"
 	"bytes"
 	"io"
 	"testing"
@@ -74,3 +75,46 @@ func TestReader(t *testing.T) {
 		t.Error("expecting EOF")
 	}
 }
+
+func TestZipReader(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, h := range []*zip.FileHeader{
+		{Name: "encrypted.xml", Flags: 0x1},
+		{Name: "lzma.xml", Method: 14},
+	} {
+		w, _ := zw.CreateRaw(h)
+		w.Write([]byte("junk"))
+	}
+	w, _ := zw.Create("good.xml")
+	w.Write([]byte("<xml/>"))
+	zw.Close()
+	bufs := siegreader.New()
+	b, err := bufs.Get(bytes.NewReader(buf.Bytes()))
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	rdr, err := zipRdr(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{"encrypted", "unsupported method (14)", ""} {
+		if err := rdr.Next(); err != nil {
+			t.Fatal(err)
+		}
+		eb, err := rdr.SetSource(bufs)
+		rdr.Close()
+		if expect == "" {
+			if (err != nil && err != io.EOF) || eb == nil {
+				t.Errorf("%s: expecting a buffer, got %v", rdr.Name(), err)
+			}
+			continue
+		}
+		if eb != nil || err == nil || err.Error() != expect {
+			t.Errorf("%s: expecting %s error, got %v", rdr.Name(), expect, err)
+		}
+	}
+	if rdr.Next() != io.EOF {
+		t.Error("expecting EOF")
+	}
+}
@@ -16,6 +16,8 @@ package containermatcher
 
 import (
 	"archive/zip"
+	"errors"
+	"fmt"
 	"io"
 	"strings"
 
@@ -40,13 +42,22 @@ func (z *zipReader) Name() string {
 	return strings.TrimSuffix(z.rdr.File[z.idx].Name, "\x00") // non-spec zip files may have null terminated strings
 }
 
+// SetSource returns an error, and no buffer, if the entry is encrypted, uses an unsupported compression method or has a corrupt header.
+// Container matching continues with the remaining entries.
 func (z *zipReader) SetSource(bufs *siegreader.Buffers) (*siegreader.Buffer, error) {
+	f := z.rdr.File[z.idx]
+	if f.Flags&0x1 == 0x1 {
+		return nil, errors.New("encrypted")
+	}
 	var err error
-	z.rc, err = z.rdr.File[z.idx].Open()
-	if err != nil {
-		return nil, err
+	z.rc, err = f.Open()
+	switch err {
+	case nil:
+		return bufs.Get(z.rc)
+	case zip.ErrAlgorithm:
+		return nil, fmt.Errorf("unsupported method (%d)", f.Method)
 	}
-	return bufs.Get(z.rc)
+	return nil, errors.New("corrupt")
 }
 
 func (z *zipReader) Close() {
@@ -54,6 +65,7 @@ func (z *zipReader) Close() {
 		return
 	}
 	z.rc.Close()
+	z.rc = nil
 }
 
 func (z *zipReader) IsDir() bool {
@@ -34,15 +34,17 @@ const (
 	errString  = "[ERROR]"
 	warnString = "[WARN]"
 	timeString = "[TIME]"
+	skipString = "[SKIP]"
 )
 
 // Logger logs characteristics of the matching process depending on options set by user.
 type Logger struct {
-	progress, e, warn, known, unknown bool
-	fmts                              map[string]bool
-	cht                               map[string]map[string]int
-	w                                 io.Writer
-	start                             time.Time
+	progress, e, warn, known, unknown, skip bool
+	fmts                                    map[string]bool
+	cht                                     map[string]map[string]int
+	w                                       io.Writer
+	start                                   time.Time
+	trk                                     *tracker
 	// mutate
 	fp bool
 }
@@ -61,6 +63,8 @@ func New(opts string) (*Logger, error) {
 			lg.w = os.Stdout
 		case "progress", "p":
 			lg.progress = true
+		case "eta":
+			lg.trk = newTracker()
 		case "time", "t":
 			lg.start = time.Now()
 		case "error", "err", "e":
@@ -75,6 +79,8 @@ func New(opts string) (*Logger, error) {
 			lg.unknown = true
 		case "known", "k":
 			lg.known = true
+		case "skip", "x":
+			lg.skip = true
 		case "chart", "c":
 			lg.cht = make(map[string]map[string]int)
 		default:
@@ -91,6 +97,9 @@ func New(opts string) (*Logger, error) {
 		lg.progress = false // progress reported internally
 		config.SetOut(lg.w)
 	}
+	if lg.trk != nil {
+		lg.trk.w = lg.w
+	}
 	return lg, nil
 }
 
@@ -137,8 +146,11 @@ func (lg *Logger) Chart() {
 	fmt.Fprint(lg.w, chart.Chart("[Chart]", sections, fields, map[string]bool{}, lg.cht))
 }
 
-// Close prints and chart and time elapsed
+// Close prints a final progress report, chart and time elapsed
 func (lg *Logger) Close() {
+	if lg.trk != nil {
+		lg.trk.close()
+	}
 	lg.Chart()
 	lg.Elapsed()
 }
@@ -159,6 +171,14 @@ func (lg *Logger) Error(p string, e error) {
 	}
 }
 
+// Skip logs files and directories skipped in directory walks.
+func (lg *Logger) Skip(p string, reason error) {
+	if lg.skip {
+		lg.fp = printFile(lg.fp, lg.w, p)
+		fmt.Fprintf(lg.w, "%s %v\n", skipString, reason)
+	}
+}
+
 // IDs logs warnings, known, unknown and reports matches against supplied formats.
 func (lg *Logger) IDs(p string, ids []core.Identification) {
 	if !lg.warn && !lg.known && !lg.unknown && lg.fmts == nil && lg.cht == nil {
@@ -0,0 +1,184 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const progressString = "[PROGRESS]"
+
+// progressInterval is how often progress is reported
+var progressInterval = time.Second
+
+// tracker counts the files and bytes scanned, and periodically reports progress with throughput and, once the totals
+// are known, an estimated time to completion.
+type tracker struct {
+	mu          sync.Mutex
+	start       time.Time
+	files       int64
+	bytes       int64
+	totalFiles  int64
+	totalBytes  int64
+	counted     bool      // totals are set
+	w           io.Writer // human readable reports, if not nil
+	jw          io.Writer // JSON lines reports, if not nil
+	once        sync.Once
+	stop, ended chan struct{}
+}
+
+// progressReport is a JSON lines progress report. Totals, percent and eta are only reported once the totals are known.
+type progressReport struct {
+	Files      int64   `json:"files"`
+	Bytes      int64   `json:"bytes"`
+	TotalFiles int64   `json:"totalfiles,omitempty"`
+	TotalBytes int64   `json:"totalbytes,omitempty"`
+	Percent    float64 `json:"percent,omitempty"`
+	Elapsed    float64 `json:"elapsed"` // seconds
+	Rate       float64 `json:"rate"`    // bytes per second
+	ETA        float64 `json:"eta,omitempty"`
+	Done       bool    `json:"done"`
+}
+
+func newTracker() *tracker {
+	return &tracker{start: time.Now(), stop: make(chan struct{}), ended: make(chan struct{})}
+}
+
+func (t *tracker) run() {
+	t.once.Do(func() {
+		go func() {
+			ticker := time.NewTicker(progressInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					t.report(false)
+				case <-t.stop:
+					close(t.ended)
+					return
+				}
+			}
+		}()
+	})
+}
+
+func (t *tracker) close() {
+	t.run() // so there is a goroutine to stop
+	close(t.stop)
+	<-t.ended
+	t.report(true)
+}
+
+func (t *tracker) report(final bool) {
+	t.mu.Lock()
+	elapsed := time.Since(t.start)
+	r := progressReport{Files: t.files, Bytes: t.bytes, Elapsed: elapsed.Seconds(), Done: final}
+	if r.Elapsed > 0 {
+		r.Rate = float64(t.bytes) / r.Elapsed
+	}
+	if t.counted {
+		r.TotalFiles, r.TotalBytes = t.totalFiles, t.totalBytes
+		done, total := float64(t.bytes), float64(t.totalBytes)
+		if t.totalBytes == 0 {
+			done, total = float64(t.files), float64(t.totalFiles)
+		}
+		r.Percent = 100
+		if total > done {
+			r.Percent = 100 * done / total
+			if done > 0 {
+				r.ETA = r.Elapsed * (total - done) / done
+			}
+		}
+	}
+	t.mu.Unlock()
+	if t.w != nil {
+		fmt.Fprintf(t.w, "%s %s\n", progressString, r)
+	}
+	if t.jw != nil {
+		byts, _ := json.Marshal(r)
+		t.jw.Write(append(byts, '\n'))
+	}
+}
+
+func (r progressReport) String() string {
+	rate := size(int64(r.Rate)) + "/s"
+	elapsed := time.Duration(r.Elapsed * float64(time.Second)).Round(time.Second)
+	switch {
+	case r.Done:
+		return fmt.Sprintf("%d files, %s in %v (%s)", r.Files, size(r.Bytes), elapsed, rate)
+	case r.TotalFiles == 0 && r.TotalBytes == 0:
+		return fmt.Sprintf("%d files, %s, %s", r.Files, size(r.Bytes), rate)
+	}
+	eta := "unknown"
+	if r.ETA > 0 || r.Percent == 100 {
+		eta = time.Duration(r.ETA * float64(time.Second)).Round(time.Second).String()
+	}
+	return fmt.Sprintf("%d/%d files, %s/%s (%.0f%%), %s, ETA %s",
+		r.Files, r.TotalFiles, size(r.Bytes), size(r.TotalBytes), r.Percent, rate, eta)
+}
+
+// size formats a number of bytes e.g. 1.5 MiB
+func size(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// ProgressJSON reports progress as JSON lines, e.g. to a file descriptor read by a GUI.
+func (lg *Logger) ProgressJSON(w io.Writer) {
+	if lg.trk == nil {
+		lg.trk = newTracker()
+	}
+	lg.trk.jw = w
+}
+
+// Tracking reports whether progress is reported with totals (the eta option or ProgressJSON), in which case the caller
+// should count the files and bytes to be scanned and call SetTotal.
+func (lg *Logger) Tracking() bool {
+	return lg.trk != nil
+}
+
+// SetTotal sets the number of files and bytes to be scanned. It can be called while the scan is underway.
+func (lg *Logger) SetTotal(files, bytes int64) {
+	if lg.trk == nil {
+		return
+	}
+	lg.trk.mu.Lock()
+	lg.trk.totalFiles, lg.trk.totalBytes, lg.trk.counted = files, bytes, true
+	lg.trk.mu.Unlock()
+}
+
+// Done records a file that has been scanned (but not the contents of archives, which aren't included in the totals).
+func (lg *Logger) Done(sz int64) {
+	if lg.trk == nil {
+		return
+	}
+	lg.trk.run()
+	lg.trk.mu.Lock()
+	lg.trk.files++
+	lg.trk.bytes += sz
+	lg.trk.mu.Unlock()
+}
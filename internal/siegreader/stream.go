@@ -203,6 +203,11 @@ func (s *stream) CanSeek(o int64, rev bool) (bool, error) {
 	if rev {
 		var err error
 		for _, err = s.fill(); err == nil; _, err = s.fill() {
+			select {
+			case <-s.b.Quit:
+				return false, ErrQuit
+			default:
+			}
 		}
 		if err != io.EOF {
 			return false, err
@@ -24,22 +24,75 @@ type Archive int
 
 // Archive type enum.
 const (
-	None Archive = iota // None means the format cannot be decompressed by sf.
-	Zip                 // Zip describes a Zip type archive.
-	Gzip                // Gzip describes a Gzip type archive.	.
-	Tar                 // Tar describes a Tar type archive
-	ARC                 // ARC describes an ARC web archive.
-	WARC                // WARC describes a WARC web archive.
+	None     Archive = iota // None means the format cannot be decompressed by sf.
+	Zip                     // Zip describes a Zip type archive.
+	Gzip                    // Gzip describes a Gzip type archive.	.
+	Tar                     // Tar describes a Tar type archive
+	ARC                     // ARC describes an ARC web archive.
+	WARC                    // WARC describes a WARC web archive.
+	SevenZip                // SevenZip describes a 7z type archive.
+	Bzip2                   // Bzip2 describes a bzip2 compressed file.
+	XZ                      // XZ describes an xz compressed file.
+	Zstd                    // Zstd describes a Zstandard compressed file.
+	ISO                     // ISO describes an ISO 9660 disc image.
+	MBOX                    // MBOX describes an mbox file of email messages.
+	EML                     // EML describes an RFC 5322 email message.
+	MSG                     // MSG describes an Outlook email message.
+	AR                      // AR describes a Unix ar archive, including Debian packages.
+	CPIO                    // CPIO describes a cpio archive.
+	RPM                     // RPM describes an RPM package.
 )
 
+// firstRegistered is the Archive value of the first archive type added with
+// RegisterArchive. It must follow the last built-in type.
+const firstRegistered = RPM + 1
+
 const (
-	zipArc  = "zip"
-	tarArc  = "tar"
-	gzipArc = "gzip"
-	warcArc = "warc"
-	arcArc  = "arc"
+	zipArc      = "zip"
+	tarArc      = "tar"
+	gzipArc     = "gzip"
+	warcArc     = "warc"
+	arcArc      = "arc"
+	sevenZipArc = "7z"
+	bzip2Arc    = "bzip2"
+	xzArc       = "xz"
+	zstdArc     = "zstd"
+	isoArc      = "iso"
+	mboxArc     = "mbox"
+	emlArc      = "eml"
+	msgArc      = "msg"
+	arArc       = "ar"
+	cpioArc     = "cpio"
+	rpmArc      = "rpm"
 )
 
+// registeredArc is an archive type added with RegisterArchive.
+type registeredArc struct {
+	name     string
+	ids      []string
+	selected bool // set by SetArchiveFilterPermissive
+}
+
+var registered []registeredArc
+
+// RegisterArchive adds a named archive type that matches any of the given
+// identifiers (PUIDs, MIME types, LOC or Wikidata IDs) and returns its
+// Archive value. Registering an existing name replaces its identifiers.
+// When selected, registered archive types take precedence over built-in
+// types that match the same identifiers. RegisterArchive should be called
+// before identification begins, e.g. in an init function.
+func RegisterArchive(name string, ids ...string) Archive {
+	name = strings.ToLower(name)
+	for i, r := range registered {
+		if r.name == name {
+			registered[i].ids = ids
+			return firstRegistered + Archive(i)
+		}
+	}
+	registered = append(registered, registeredArc{name: name, ids: ids})
+	return firstRegistered + Archive(len(registered)-1)
+}
+
 // ArcZipTypes returns a string array with all Zip identifiers Siegfried
 // can match and decompress.
 func ArcZipTypes() []string {
@@ -88,23 +141,160 @@ func ArcArcTypes() []string {
 func ArcWarcTypes() []string {
 	return []string{
 		pronom.warc,
+		pronom.warc1_0,
+		pronom.warc1_1,
 		mimeinfo.warc,
 		loc.warc,
 		wikidata.warc,
 	}
 }
 
+// ArcSevenZipTypes returns a string array with all 7z identifiers
+// Siegfried can match and decompress.
+func ArcSevenZipTypes() []string {
+	return []string{
+		pronom.sevenZip,
+		mimeinfo.sevenZip,
+		loc.sevenZip,
+		wikidata.sevenZip,
+	}
+}
+
+// ArcBzip2Types returns a string array with all bzip2 identifiers
+// Siegfried can match and decompress.
+func ArcBzip2Types() []string {
+	return []string{
+		pronom.bzip2,
+		mimeinfo.bzip2,
+		wikidata.bzip2,
+	}
+}
+
+// ArcXZTypes returns a string array with all xz identifiers Siegfried
+// can match and decompress.
+func ArcXZTypes() []string {
+	return []string{
+		pronom.xz,
+		mimeinfo.xz,
+	}
+}
+
+// ArcZstdTypes returns a string array with all Zstandard identifiers
+// Siegfried can match and decompress.
+func ArcZstdTypes() []string {
+	return []string{
+		mimeinfo.zstd,
+		wikidata.zstd,
+	}
+}
+
+// ArcISOTypes returns a string array with all ISO 9660 identifiers
+// Siegfried can match and decompress.
+func ArcISOTypes() []string {
+	return []string{
+		pronom.iso,
+		pronom.isoUDF,
+		pronom.isoAPM,
+		pronom.isoAPMUDF,
+		mimeinfo.iso,
+	}
+}
+
+// ArcMBOXTypes returns a string array with all mbox identifiers
+// Siegfried can match and decompress.
+func ArcMBOXTypes() []string {
+	return []string{
+		pronom.mbox,
+		mimeinfo.mbox,
+		loc.mbox,
+		wikidata.mbox,
+	}
+}
+
+// ArcEMLTypes returns a string array with all email message identifiers
+// Siegfried can match and decompress.
+func ArcEMLTypes() []string {
+	return []string{
+		pronom.eml,
+		pronom.mimeEml,
+		mimeinfo.eml,
+		loc.eml,
+	}
+}
+
+// ArcMSGTypes returns a string array with all Outlook message
+// identifiers Siegfried can match and decompress.
+func ArcMSGTypes() []string {
+	return []string{
+		pronom.msg,
+		mimeinfo.msg,
+		loc.msg,
+	}
+}
+
+// ArcARTypes returns a string array with all ar identifiers, including
+// Debian packages, Siegfried can match and decompress.
+func ArcARTypes() []string {
+	return []string{
+		pronom.ar,
+		mimeinfo.ar,
+		mimeinfo.deb,
+		mimeinfo.debTika,
+	}
+}
+
+// ArcCPIOTypes returns a string array with all cpio identifiers
+// Siegfried can match and decompress.
+func ArcCPIOTypes() []string {
+	return []string{
+		pronom.cpio,
+		mimeinfo.cpio,
+		mimeinfo.sv4cpio,
+	}
+}
+
+// ArcRPMTypes returns a string array with all RPM package identifiers
+// Siegfried can match and decompress.
+func ArcRPMTypes() []string {
+	return []string{
+		pronom.rpm1,
+		pronom.rpm2,
+		pronom.rpm3,
+		mimeinfo.rpm,
+		mimeinfo.srpm,
+	}
+}
+
 // ListAllArcTypes returns a list of archive file-format extensions that
 // can be used to filter the files Siegfried will decompress to identify
 // the contents of.
 func ListAllArcTypes() string {
-	return fmt.Sprintf("%s, %s, %s, %s, %s",
+	return fmt.Sprintf("%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s",
 		zipArc,
 		tarArc,
 		gzipArc,
 		warcArc,
 		arcArc,
-	)
+		sevenZipArc,
+		bzip2Arc,
+		xzArc,
+		zstdArc,
+		isoArc,
+		mboxArc,
+		emlArc,
+		msgArc,
+		arArc,
+		cpioArc,
+		rpmArc,
+	) + listRegistered()
+}
+
+func listRegistered() string {
+	var ret string
+	for _, r := range registered {
+		ret += ", " + r.name
+	}
+	return ret
 }
 
 var permissiveFilter []string
@@ -116,9 +306,13 @@ var permissiveFilter []string
 // -z flag is used.
 func SetArchiveFilterPermissive(value string) []string {
 	arr := []string{}
+	for i := range registered {
+		registered[i].selected = false
+	}
 	arcList := strings.Split(value, ",")
 	for _, arc := range arcList {
-		switch strings.TrimSpace(strings.ToLower(arc)) {
+		arc = strings.TrimSpace(strings.ToLower(arc))
+		switch arc {
 		case zipArc:
 			arr = append(arr, ArcZipTypes()...)
 		case tarArc:
@@ -129,6 +323,34 @@ func SetArchiveFilterPermissive(value string) []string {
 			arr = append(arr, ArcWarcTypes()...)
 		case arcArc:
 			arr = append(arr, ArcArcTypes()...)
+		case sevenZipArc:
+			arr = append(arr, ArcSevenZipTypes()...)
+		case bzip2Arc:
+			arr = append(arr, ArcBzip2Types()...)
+		case xzArc:
+			arr = append(arr, ArcXZTypes()...)
+		case zstdArc:
+			arr = append(arr, ArcZstdTypes()...)
+		case isoArc:
+			arr = append(arr, ArcISOTypes()...)
+		case mboxArc:
+			arr = append(arr, ArcMBOXTypes()...)
+		case emlArc:
+			arr = append(arr, ArcEMLTypes()...)
+		case msgArc:
+			arr = append(arr, ArcMSGTypes()...)
+		case arArc:
+			arr = append(arr, ArcARTypes()...)
+		case cpioArc:
+			arr = append(arr, ArcCPIOTypes()...)
+		case rpmArc:
+			arr = append(arr, ArcRPMTypes()...)
+		}
+		for i, r := range registered {
+			if r.name == arc {
+				arr = append(arr, r.ids...)
+				registered[i].selected = true
+			}
 		}
 	}
 	permissiveFilter = arr
@@ -141,6 +363,16 @@ func archiveFilterPermissive() []string {
 	return permissiveFilter
 }
 
+// Selected reports whether an archive format is unpacked with the current archive filter.
+func (a Archive) Selected() bool {
+	for _, id := range permissiveFilter {
+		if IsArchive(id) == a {
+			return true
+		}
+	}
+	return false
+}
+
 func (a Archive) String() string {
 	switch a {
 	case Zip:
@@ -153,6 +385,31 @@ func (a Archive) String() string {
 		return "ARC"
 	case WARC:
 		return "WARC"
+	case SevenZip:
+		return "7z"
+	case Bzip2:
+		return "bzip2"
+	case XZ:
+		return "xz"
+	case Zstd:
+		return "zstd"
+	case ISO:
+		return "ISO"
+	case MBOX:
+		return "MBOX"
+	case EML:
+		return "EML"
+	case MSG:
+		return "MSG"
+	case AR:
+		return "ar"
+	case CPIO:
+		return "cpio"
+	case RPM:
+		return "RPM"
+	}
+	if a >= firstRegistered && int(a-firstRegistered) < len(registered) {
+		return registered[a-firstRegistered].name
 	}
 	return ""
 }
@@ -296,6 +296,11 @@ func IsArchive(id string) Archive {
 	if !contains(id, archiveFilterPermissive()) {
 		return None
 	}
+	for i, r := range registered {
+		if r.selected && contains(id, r.ids) {
+			return firstRegistered + Archive(i)
+		}
+	}
 	switch {
 	case contains(id, ArcZipTypes()):
 		return Zip
@@ -307,6 +312,28 @@ func IsArchive(id string) Archive {
 		return ARC
 	case contains(id, ArcWarcTypes()):
 		return WARC
+	case contains(id, ArcSevenZipTypes()):
+		return SevenZip
+	case contains(id, ArcBzip2Types()):
+		return Bzip2
+	case contains(id, ArcXZTypes()):
+		return XZ
+	case contains(id, ArcZstdTypes()):
+		return Zstd
+	case contains(id, ArcISOTypes()):
+		return ISO
+	case contains(id, ArcMBOXTypes()):
+		return MBOX
+	case contains(id, ArcEMLTypes()):
+		return EML
+	case contains(id, ArcMSGTypes()):
+		return MSG
+	case contains(id, ArcARTypes()):
+		return AR
+	case contains(id, ArcCPIOTypes()):
+		return CPIO
+	case contains(id, ArcRPMTypes()):
+		return RPM
 	}
 	return None
 }
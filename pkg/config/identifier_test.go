@@ -18,6 +18,7 @@
 package config
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -27,6 +28,16 @@ var locArcUID = "fdd000235"
 var mimeTarUID = "application/x-tar"
 var mimeWarcUID = "application/x-warc"
 var mimeGzipUID = "application/gzip"
+var proSevenZipUID = "fmt/484"
+var proXZUID = "fmt/1098"
+var mimeZstdUID = "application/zstd"
+var proISOUID = "fmt/468"
+var proMboxUID = "fmt/720"
+var mimeEmlUID = "message/rfc822"
+var locMsgUID = "fdd000379"
+var mimeDebUID = "application/vnd.debian.binary-package"
+var proCPIOUID = "fmt/635"
+var proRPMUID = "fmt/795"
 
 // Non-archive UID.
 var nonArcUID = "fmt/1000"
@@ -46,9 +57,24 @@ var isArcTests = []arcTest{
 	arcTest{"gZip", mimeGzipUID, Gzip},
 	arcTest{"warc,zip,tar", mimeWarcUID, WARC},
 	arcTest{"zip,arc", locArcUID, ARC},
+	arcTest{"7z", proSevenZipUID, SevenZip},
+	arcTest{"tar,xz", proXZUID, XZ},
+	arcTest{ListAllArcTypes(), mimeZstdUID, Zstd},
+	arcTest{"iso", proISOUID, ISO},
+	arcTest{"mbox,eml", proMboxUID, MBOX},
+	arcTest{"eml", mimeEmlUID, EML},
+	arcTest{ListAllArcTypes(), locMsgUID, MSG},
+	arcTest{"ar", mimeDebUID, AR},
+	arcTest{"ar", "fmt/1835", AR},
+	arcTest{"cpio,rpm", proCPIOUID, CPIO},
+	arcTest{ListAllArcTypes(), proRPMUID, RPM},
 	// Negative tests should all return None.
 	arcTest{"zip,arc", mimeWarcUID, None},
 	arcTest{"zip,arc", mimeGzipUID, None},
+	arcTest{"zip,tar", proSevenZipUID, None},
+	arcTest{"gzip,bzip2", proXZUID, None},
+	arcTest{"mbox,msg", mimeEmlUID, None},
+	arcTest{"cpio", proRPMUID, None},
 	arcTest{ListAllArcTypes(), nonArcUID, None},
 	arcTest{"", nonArcUID, None},
 }
@@ -68,7 +94,7 @@ func TestIsArchivePositive(t *testing.T) {
 	}
 }
 
-var arcTypes = [...]Archive{Zip, Gzip, Tar, ARC, WARC}
+var arcTypes = [...]Archive{Zip, Gzip, Tar, ARC, WARC, SevenZip, Bzip2, XZ, Zstd, ISO, MBOX, EML, MSG, AR, CPIO, RPM}
 
 const noneType = None
 
@@ -84,3 +110,33 @@ func TestIsArchiveGreaterThanNone(t *testing.T) {
 		t.Errorf("Archive 0 type should equal zero not %d", noneType)
 	}
 }
+
+func TestRegisterArchive(t *testing.T) {
+	arc := RegisterArchive("BagIt", "application/x-bagit", proZipUID)
+	if arc < firstRegistered || arc.String() != "bagit" {
+		t.Fatalf("Unexpected registered archive '%s' (%d)", arc, arc)
+	}
+	if !strings.HasSuffix(ListAllArcTypes(), ", bagit") {
+		t.Errorf("Expecting bagit in list of archive types, got '%s'", ListAllArcTypes())
+	}
+	tests := []arcTest{
+		{"bagit", "application/x-bagit", arc},
+		{"zip,bagit", proZipUID, arc}, // registered types take precedence
+		{"zip", proZipUID, Zip},
+		{"zip", "application/x-bagit", None},
+	}
+	for _, test := range tests {
+		SetArchiveFilterPermissive(test.filter)
+		if got := IsArchive(test.uid); got != test.result {
+			t.Errorf("Unexpected test result '%s', expected '%s'", got, test.result)
+		}
+	}
+	if again := RegisterArchive("bagit", "application/x-bagit"); again != arc {
+		t.Errorf("Re-registering should return '%d', got '%d'", arc, again)
+	}
+	SetArchiveFilterPermissive("zip,bagit")
+	if got := IsArchive(proZipUID); got != Zip {
+		t.Errorf("Unexpected test result '%s' after re-registering, expected '%s'", got, Zip)
+	}
+	SetArchiveFilterPermissive("")
+}
@@ -26,13 +26,21 @@ var loc = struct {
 	tar      string // n/a
 	arc      string
 	warc     string
+	sevenZip string
+	mbox     string
+	eml      string
+	msg      string
 	text     string // n/a
 }{
-	def:  "fddXML.zip",
-	name: "loc",
-	zip:  "fdd000354",
-	arc:  "fdd000235",
-	warc: "fdd000236",
+	def:      "fddXML.zip",
+	name:     "loc",
+	zip:      "fdd000354",
+	arc:      "fdd000235",
+	warc:     "fdd000236",
+	sevenZip: "fdd000539",
+	mbox:     "fdd000383",
+	eml:      "fdd000388",
+	msg:      "fdd000379",
 }
 
 // LOC returns the location of the LOC signature file.
@@ -29,6 +29,21 @@ var mimeinfo = struct {
 	tar      string
 	arc      string
 	warc     string
+	sevenZip string
+	bzip2    string
+	xz       string
+	zstd     string
+	iso      string
+	mbox     string
+	eml      string
+	msg      string
+	ar       string
+	deb      string // freedesktop.org
+	debTika  string
+	cpio     string
+	sv4cpio  string
+	rpm      string
+	srpm     string
 	text     string
 }{
 	versions: "mime-info.json",
@@ -37,6 +52,21 @@ var mimeinfo = struct {
 	tar:      "application/x-tar",
 	arc:      "application/x-arc",
 	warc:     "application/x-warc",
+	sevenZip: "application/x-7z-compressed",
+	bzip2:    "application/x-bzip2",
+	xz:       "application/x-xz",
+	zstd:     "application/zstd",
+	iso:      "application/x-iso9660-image",
+	mbox:     "application/mbox",
+	eml:      "message/rfc822",
+	msg:      "application/vnd.ms-outlook",
+	ar:       "application/x-archive",
+	deb:      "application/vnd.debian.binary-package",
+	debTika:  "application/x-debian-package",
+	cpio:     "application/x-cpio",
+	sv4cpio:  "application/x-sv4cpio",
+	rpm:      "application/x-rpm",
+	srpm:     "application/x-source-rpm",
 	text:     "text/plain",
 }
 
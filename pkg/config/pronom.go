@@ -38,12 +38,33 @@ var pronom = struct {
 	harvestThrottle  time.Duration
 	harvestTransport *http.Transport
 	// archive puids
-	zip    string
-	tar    string
-	gzip   string
-	arc    string
-	arc1_1 string
-	warc   string
+	zip      string
+	tar      string
+	gzip     string
+	arc      string
+	arc1_1   string
+	warc     string
+	warc1_0  string
+	warc1_1  string
+	sevenZip string
+	bzip2    string
+	xz       string
+	// disc image puids
+	iso       string
+	isoUDF    string // UDF-ISO 9660 bridge
+	isoAPM    string // Apple Partition Map ISO 9660 hybrid
+	isoAPMUDF string // Apple Partition Map - ISO 9660 - UDF hybrid
+	// email puids
+	mbox    string
+	eml     string
+	mimeEml string // MIME Email
+	msg     string
+	// unix archive and package puids
+	ar   string
+	cpio string
+	rpm1 string
+	rpm2 string
+	rpm3 string
 	// text puid
 	text string
 }{
@@ -59,6 +80,24 @@ var pronom = struct {
 	arc:              "x-fmt/219",
 	arc1_1:           "fmt/410",
 	warc:             "fmt/289",
+	warc1_0:          "fmt/1355",
+	warc1_1:          "fmt/1281",
+	sevenZip:         "fmt/484",
+	bzip2:            "x-fmt/268",
+	xz:               "fmt/1098",
+	iso:              "fmt/468",
+	isoUDF:           "fmt/1739",
+	isoAPM:           "fmt/1741",
+	isoAPMUDF:        "fmt/1757",
+	mbox:             "fmt/720",
+	eml:              "fmt/278",
+	mimeEml:          "fmt/950",
+	msg:              "x-fmt/430",
+	ar:               "fmt/1835",
+	cpio:             "fmt/635",
+	rpm1:             "fmt/793",
+	rpm2:             "fmt/794",
+	rpm3:             "fmt/795",
 	text:             "x-fmt/111",
 }
 
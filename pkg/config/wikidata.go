@@ -32,11 +32,15 @@ import (
 var wikidata = struct {
 	// archive formats that Siegfried should be able to decompress via
 	// the Wikidata identifier.
-	arc    string
-	arc1_1 string
-	gzip   string
-	tar    string
-	warc   string
+	arc      string
+	arc1_1   string
+	gzip     string
+	tar      string
+	warc     string
+	sevenZip string
+	bzip2    string
+	zstd     string
+	mbox     string
 	// debug provides a way for users to output errors and warnings
 	// associated with Wikidata records.
 	debug bool
@@ -106,6 +110,10 @@ var wikidata = struct {
 	gzip:                   "Q27824060",
 	tar:                    "Q283579",
 	warc:                   "Q10287816",
+	sevenZip:               "Q105853878",
+	bzip2:                  "Q27866052",
+	zstd:                   "Q105853477",
+	mbox:                   "Q105863871",
 	definitions:            "wikidata-definitions-3.0.0",
 	endpoint:               "https://query.wikidata.org/sparql",
 	filemode:               0644,
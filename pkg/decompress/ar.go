@@ -0,0 +1,167 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decompress
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Unix ar archives (including Debian .deb packages and .a static libraries).
+// GNU (SysV) and BSD long file names are supported. Symbol tables are skipped.
+
+const (
+	arMagic     = "!<arch>\n"
+	arHeaderLen = 60
+)
+
+var errAR = errors.New("ar: invalid header")
+
+type arD struct {
+	p       string
+	rdr     io.Reader
+	lr      *io.LimitedReader // the current entry's data
+	pad     bool              // entries are padded to an even length
+	long    []byte            // GNU long name table
+	name    string
+	sz      int64
+	mod     time.Time
+	written map[string]bool
+}
+
+func newAR(r io.Reader, path string) (Decompressor, error) {
+	magic := make([]byte, len(arMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, err
+	}
+	if string(magic) != arMagic {
+		return nil, errors.New("ar: invalid magic")
+	}
+	return &arD{p: path, rdr: r}, nil
+}
+
+func (a *arD) Next() error {
+	hdr := make([]byte, arHeaderLen)
+	for {
+		// skip the remainder of the previous entry
+		if a.lr != nil {
+			if err := skip(a.lr, a.lr.N); err != nil {
+				return err
+			}
+			// the final entry's padding may be omitted
+			if a.pad {
+				if err := skip(a.rdr, 1); err != nil && err != io.ErrUnexpectedEOF {
+					return err
+				}
+			}
+		}
+		if _, err := io.ReadFull(a.rdr, hdr); err != nil {
+			return err
+		}
+		if string(hdr[58:]) != "`\n" {
+			return errAR
+		}
+		mod, err := strconv.ParseInt(strings.TrimSpace(string(hdr[16:28])), 10, 64)
+		if err != nil {
+			mod = 0
+		}
+		sz, err := strconv.ParseInt(strings.TrimSpace(string(hdr[48:58])), 10, 64)
+		if err != nil || sz < 0 {
+			return errAR
+		}
+		a.lr = &io.LimitedReader{R: a.rdr, N: sz}
+		a.pad = sz%2 == 1
+		a.sz = sz
+		a.mod = time.Unix(mod, 0)
+		name := strings.TrimRight(string(hdr[:16]), " ")
+		switch {
+		case name == "/" || name == "/SYM64/" || strings.HasPrefix(name, "__.SYMDEF"): // symbol tables
+			continue
+		case name == "//": // GNU long name table
+			if a.long, err = io.ReadAll(a.lr); err != nil {
+				return err
+			}
+			continue
+		case strings.HasPrefix(name, "#1/"): // BSD long name, stored at the start of the entry's data
+			l, err := strconv.Atoi(name[3:])
+			if err != nil || int64(l) > sz {
+				return errAR
+			}
+			byt := make([]byte, l)
+			if _, err := io.ReadFull(a.lr, byt); err != nil {
+				return err
+			}
+			a.name = string(bytes.TrimRight(byt, "\x00"))
+			a.sz = sz - int64(l)
+		case len(name) > 1 && name[0] == '/': // GNU long name, an offset into the long name table
+			off, err := strconv.Atoi(name[1:])
+			if err != nil || off >= len(a.long) {
+				return errAR
+			}
+			end := bytes.Index(a.long[off:], []byte("/\n"))
+			if end < 0 {
+				end = len(a.long) - off
+			}
+			a.name = string(a.long[off : off+end])
+		default:
+			a.name = strings.TrimSuffix(name, "/")
+		}
+		return nil
+	}
+}
+
+func (a *arD) Reader() io.Reader {
+	return a.lr
+}
+
+func (a *arD) Path() string {
+	return Arcpath(a.p, filepath.FromSlash(a.name))
+}
+
+func (a *arD) MIME() string {
+	return ""
+}
+
+func (a *arD) Size() int64 {
+	return a.sz
+}
+
+func (a *arD) Mod() time.Time {
+	return a.mod
+}
+
+func (a *arD) Dirs() []string {
+	if a.written == nil {
+		a.written = make(map[string]bool)
+	}
+	return dirs(a.p, a.name, a.written)
+}
+
+// skip discards n bytes from r
+func skip(r io.Reader, n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	_, err := io.CopyN(io.Discard, r, n)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package decompress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+func arEntry(name string, data []byte) []byte {
+	hdr := fmt.Sprintf("%-16s%-12d%-6d%-6d%-8s%-10d`\n", name, 1600000000, 0, 0, "100644", len(data))
+	byt := append([]byte(hdr), data...)
+	if len(data)%2 == 1 {
+		byt = append(byt, '\n')
+	}
+	return byt
+}
+
+func TestAR(t *testing.T) {
+	long := "a_very_long_file_name_indeed.txt"
+	gnu := []byte(arMagic)
+	gnu = append(gnu, arEntry("/", []byte{0, 0, 0, 0})...) // symbol table
+	gnu = append(gnu, arEntry("//", []byte(long+"/\n"))...)
+	gnu = append(gnu, arEntry("debian-binary/", []byte("2.0\n"))...)
+	gnu = append(gnu, arEntry("/0", []byte("odd"))...)
+	gnu = append(gnu, arEntry("data.tar.xz/", []byte("data"))...)
+	bsd := []byte(arMagic)
+	bsd = append(bsd, arEntry("__.SYMDEF", []byte{0, 0})...)
+	bsd = append(bsd, arEntry("debian-binary", []byte("2.0\n"))...)
+	bsd = append(bsd, arEntry(fmt.Sprintf("#1/%d", len(long)), append([]byte(long), "odd"...))...)
+	bsd = append(bsd, arEntry("data.tar.xz", []byte("data"))...)
+	bufs := siegreader.New()
+	for _, v := range [][]byte{gnu, bsd} {
+		buf, err := bufs.Get(bytes.NewReader(v))
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		d, err := New(config.AR, buf, "test.deb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expect := range [][2]string{{"debian-binary", "2.0\n"}, {long, "odd"}, {"data.tar.xz", "data"}} {
+			if err = d.Next(); err != nil {
+				t.Fatal(err)
+			}
+			if d.Path() != Arcpath("test.deb", expect[0]) {
+				t.Errorf("expecting %s, got %s", expect[0], d.Path())
+			}
+			if d.Size() != int64(len(expect[1])) || d.Mod().Unix() != 1600000000 {
+				t.Errorf("%s: bad size or mod time: %d, %v", expect[0], d.Size(), d.Mod())
+			}
+			if expect[0] == long { // read the contents of the odd sized entry, leave others to be skipped
+				byt, _ := io.ReadAll(d.Reader())
+				if string(byt) != expect[1] {
+					t.Errorf("%s: expecting %q, got %q", expect[0], expect[1], byt)
+				}
+			}
+		}
+		if err = d.Next(); err != io.EOF {
+			t.Errorf("expecting EOF, got %v", err)
+		}
+		bufs.Put(buf)
+	}
+}
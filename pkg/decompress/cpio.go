@@ -0,0 +1,255 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decompress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// cpio archives in the portable ASCII (odc), new ASCII (newc and crc) and old binary formats,
+// and RPM packages, whose payload is a compressed cpio archive.
+// Only regular files are unpacked: directories, links and devices are skipped.
+
+const (
+	cpioModeType = 0170000
+	cpioModeReg  = 0100000
+	cpioTrailer  = "TRAILER!!!"
+)
+
+var errCPIO = errors.New("cpio: invalid header")
+
+type cpioD struct {
+	p       string
+	rdr     io.Reader
+	lr      *io.LimitedReader // the current entry's data
+	pad     int64             // padding following the current entry's data
+	done    bool
+	close   func() // called once the trailer is reached
+	name    string
+	sz      int64
+	mod     time.Time
+	written map[string]bool
+}
+
+func newCPIO(r io.Reader, path string) (Decompressor, error) {
+	return &cpioD{p: path, rdr: r}, nil
+}
+
+func (c *cpioD) Next() error {
+	if c.done {
+		return io.EOF
+	}
+	for {
+		// skip the remainder of the previous entry
+		if c.lr != nil {
+			if err := skip(c.lr, c.lr.N); err != nil {
+				return err
+			}
+			if err := skip(c.rdr, c.pad); err != nil {
+				return err
+			}
+		}
+		mode, err := c.header()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF // archives should end with a trailer
+			}
+			return err
+		}
+		if c.name == cpioTrailer {
+			c.done = true
+			if c.close != nil {
+				c.close()
+			}
+			return io.EOF
+		}
+		if mode&cpioModeType == cpioModeReg {
+			return nil
+		}
+	}
+}
+
+// header reads an entry's header and name, and sets the entry's data reader. It returns the entry's mode.
+func (c *cpioD) header() (int64, error) {
+	magic := make([]byte, 6)
+	if _, err := io.ReadFull(c.rdr, magic); err != nil {
+		return 0, err
+	}
+	var mode, mod, namesz, sz, namepad int64
+	switch string(magic) {
+	case "070701", "070702": // newc and crc: 13 8 character hex fields
+		hdr := make([]byte, 104)
+		if _, err := io.ReadFull(c.rdr, hdr); err != nil {
+			return 0, err
+		}
+		fields := make([]int64, 13)
+		for i := range fields {
+			v, err := strconv.ParseInt(string(hdr[i*8:i*8+8]), 16, 64)
+			if err != nil {
+				return 0, errCPIO
+			}
+			fields[i] = v
+		}
+		mode, mod, sz, namesz = fields[1], fields[5], fields[6], fields[11]
+		namepad = (4 - (110+namesz)%4) % 4
+		c.pad = (4 - sz%4) % 4
+	case "070707": // odc: octal fields
+		hdr := make([]byte, 70)
+		if _, err := io.ReadFull(c.rdr, hdr); err != nil {
+			return 0, err
+		}
+		var fields []int64
+		for _, l := range []int{6, 6, 6, 6, 6, 6, 6, 11, 6, 11} {
+			v, err := strconv.ParseInt(string(hdr[:l]), 8, 64)
+			if err != nil {
+				return 0, errCPIO
+			}
+			fields = append(fields, v)
+			hdr = hdr[l:]
+		}
+		mode, mod, namesz, sz = fields[2], fields[7], fields[8], fields[9]
+		c.pad = 0
+	default: // old binary: 13 16 bit fields, in either byte order
+		var order binary.ByteOrder
+		switch {
+		case magic[0] == 0xc7 && magic[1] == 0x71:
+			order = binary.LittleEndian
+		case magic[0] == 0x71 && magic[1] == 0xc7:
+			order = binary.BigEndian
+		default:
+			return 0, errCPIO
+		}
+		hdr := make([]byte, 26)
+		copy(hdr, magic)
+		if _, err := io.ReadFull(c.rdr, hdr[6:]); err != nil {
+			return 0, err
+		}
+		u := func(i int) int64 { return int64(order.Uint16(hdr[i*2:])) }
+		mode, mod, namesz, sz = u(3), u(8)<<16|u(9), u(10), u(11)<<16|u(12)
+		namepad = namesz % 2
+		c.pad = sz % 2
+	}
+	if namesz < 1 || namesz > 4096 || sz < 0 {
+		return 0, errCPIO
+	}
+	name := make([]byte, namesz+namepad)
+	if _, err := io.ReadFull(c.rdr, name); err != nil {
+		return 0, err
+	}
+	c.name = strings.TrimLeft(strings.TrimPrefix(string(bytes.TrimRight(name, "\x00")), "./"), "/")
+	c.sz = sz
+	c.mod = time.Unix(mod, 0)
+	c.lr = &io.LimitedReader{R: c.rdr, N: sz}
+	return mode, nil
+}
+
+func (c *cpioD) Reader() io.Reader {
+	return c.lr
+}
+
+func (c *cpioD) Path() string {
+	return Arcpath(c.p, filepath.FromSlash(c.name))
+}
+
+func (c *cpioD) MIME() string {
+	return ""
+}
+
+func (c *cpioD) Size() int64 {
+	return c.sz
+}
+
+func (c *cpioD) Mod() time.Time {
+	return c.mod
+}
+
+func (c *cpioD) Dirs() []string {
+	if c.written == nil {
+		c.written = make(map[string]bool)
+	}
+	return dirs(c.p, c.name, c.written)
+}
+
+// RPM packages begin with a lead, followed by a signature header and a header. The payload that follows is a cpio archive,
+// which may be compressed.
+func newRPM(r io.Reader, path string) (Decompressor, error) {
+	lead := make([]byte, 96)
+	if _, err := io.ReadFull(r, lead); err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(lead, []byte{0xed, 0xab, 0xee, 0xdb}) {
+		return nil, errors.New("rpm: invalid lead")
+	}
+	// the signature header is padded to a multiple of 8 bytes
+	l, err := rpmHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if err = skip(r, (8-l%8)%8); err != nil {
+		return nil, err
+	}
+	if _, err = rpmHeader(r); err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(6)
+	c := &cpioD{p: path}
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		c.rdr, err = gzip.NewReader(br)
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		c.rdr = bzip2.NewReader(br)
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0}):
+		c.rdr, err = xz.NewReader(br)
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		var z *zstd.Decoder
+		z, err = zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err == nil {
+			c.rdr, c.close = z, z.Close
+		}
+	case bytes.HasPrefix(magic, []byte{0x5d, 0, 0}):
+		c.rdr, err = lzma.NewReader(br)
+	default: // uncompressed
+		c.rdr = br
+	}
+	return c, err
+}
+
+// rpmHeader skips an RPM header structure and returns its length
+func rpmHeader(r io.Reader) (int64, error) {
+	hdr := make([]byte, 16)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return 0, err
+	}
+	if !bytes.HasPrefix(hdr, []byte{0x8e, 0xad, 0xe8, 0x01}) {
+		return 0, errors.New("rpm: invalid header")
+	}
+	l := 16*int64(binary.BigEndian.Uint32(hdr[8:])) + int64(binary.BigEndian.Uint32(hdr[12:]))
+	return 16 + l, skip(r, l)
+}
@@ -0,0 +1,129 @@
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+type cpioEntry struct {
+	name string
+	mode int
+	data string
+}
+
+var cpioEntries = []cpioEntry{
+	{"./usr", 040755, ""},
+	{"./usr/bin/hello", 0100755, "hello world"},
+	{"./usr/bin/hi", 0120777, "hello"}, // symlink
+	{"./usr/share/odd.txt", 0100644, "odd"},
+	{cpioTrailer, 0, ""},
+}
+
+func makeCPIO(format string) []byte {
+	var buf bytes.Buffer
+	pad := func(n int) {
+		for ; n%4 != 0; n++ {
+			buf.WriteByte(0)
+		}
+	}
+	for _, e := range cpioEntries {
+		name := e.name + "\x00"
+		switch format {
+		case "newc":
+			fmt.Fprintf(&buf, "070701%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X", 1, e.mode, 0, 0, 1, 1600000000, len(e.data), 0, 0, 0, 0, len(name), 0)
+			buf.WriteString(name)
+			pad(110 + len(name))
+			buf.WriteString(e.data)
+			pad(len(e.data))
+		case "odc":
+			fmt.Fprintf(&buf, "070707%06o%06o%06o%06o%06o%06o%06o%011o%06o%011o", 0, 1, e.mode, 0, 0, 1, 0, 1600000000, len(name), len(e.data))
+			buf.WriteString(name)
+			buf.WriteString(e.data)
+		case "bin":
+			hdr := make([]byte, 26)
+			for i, v := range []int{070707, 0, 1, e.mode, 0, 0, 1, 0, 1600000000 >> 16, 1600000000 & 0xffff, len(name), len(e.data) >> 16, len(e.data) & 0xffff} {
+				binary.LittleEndian.PutUint16(hdr[i*2:], uint16(v))
+			}
+			buf.Write(hdr)
+			buf.WriteString(name)
+			if len(name)%2 == 1 {
+				buf.WriteByte(0)
+			}
+			buf.WriteString(e.data)
+			if len(e.data)%2 == 1 {
+				buf.WriteByte(0)
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+// makeRPM wraps a gzipped cpio archive with an RPM lead, signature and header
+func makeRPM(payload []byte) []byte {
+	rpmHdr := func(n, l int) []byte {
+		hdr := make([]byte, 16+16*n+l)
+		copy(hdr, []byte{0x8e, 0xad, 0xe8, 0x01})
+		binary.BigEndian.PutUint32(hdr[8:], uint32(n))
+		binary.BigEndian.PutUint32(hdr[12:], uint32(l))
+		return hdr
+	}
+	lead := make([]byte, 96)
+	copy(lead, []byte{0xed, 0xab, 0xee, 0xdb, 3, 0})
+	byt := append(lead, rpmHdr(2, 5)...) // 53 bytes, padded to 56
+	byt = append(byt, 0, 0, 0)
+	byt = append(byt, rpmHdr(3, 20)...)
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write(payload)
+	w.Close()
+	return append(byt, gz.Bytes()...)
+}
+
+func TestCPIO(t *testing.T) {
+	bufs := siegreader.New()
+	for _, v := range []struct {
+		arc config.Archive
+		nm  string
+		byt []byte
+	}{
+		{config.CPIO, "newc", makeCPIO("newc")},
+		{config.CPIO, "odc", makeCPIO("odc")},
+		{config.CPIO, "bin", makeCPIO("bin")},
+		{config.RPM, "rpm", makeRPM(makeCPIO("newc"))},
+	} {
+		buf, err := bufs.Get(bytes.NewReader(v.byt))
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		d, err := New(v.arc, buf, "test")
+		if err != nil {
+			t.Fatalf("%s: %v", v.nm, err)
+		}
+		for _, e := range []cpioEntry{cpioEntries[1], cpioEntries[3]} {
+			if err = d.Next(); err != nil {
+				t.Fatalf("%s: %v", v.nm, err)
+			}
+			if expect := Arcpath("test", e.name[2:]); d.Path() != expect {
+				t.Errorf("%s: expecting %s, got %s", v.nm, expect, d.Path())
+			}
+			if d.Size() != int64(len(e.data)) || d.Mod().Unix() != 1600000000 {
+				t.Errorf("%s: bad size or mod time: %d, %v", v.nm, d.Size(), d.Mod())
+			}
+			byt, _ := io.ReadAll(d.Reader())
+			if string(byt) != e.data {
+				t.Errorf("%s: expecting %q, got %q", v.nm, e.data, byt)
+			}
+		}
+		if err = d.Next(); err != io.EOF {
+			t.Errorf("%s: expecting EOF, got %v", v.nm, err)
+		}
+		bufs.Put(buf)
+	}
+}
@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package decompress provides zip, tar, gzip and webarchive decompression/unpacking
+// Package decompress provides zip, tar, gzip, bzip2, xz, zstd, 7z, ISO 9660, ar, cpio, RPM, email and webarchive decompression/unpacking
 package decompress
 
 import (
@@ -24,10 +24,13 @@ import (
 
 	"archive/tar"
 	"archive/zip"
+	"compress/bzip2"
 	"compress/gzip"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/richardlehane/characterize"
 	"github.com/richardlehane/webarchive"
+	"github.com/ulikunitz/xz"
 
 	"github.com/richardlehane/siegfried/internal/siegreader"
 	"github.com/richardlehane/siegfried/pkg/config"
@@ -51,6 +54,25 @@ func IsArc(ids []core.Identification) config.Archive {
 	return arc
 }
 
+// EntryError is returned by a Decompressor's Next method when the current entry can't be unpacked.
+// The entry's Path, Size and Mod are still available (but not its Reader) and decompression can
+// continue with the following entries.
+type EntryError string
+
+func (e EntryError) Error() string {
+	return string(e)
+}
+
+// Entry errors
+const (
+	ErrEncrypted EntryError = "encrypted"
+	ErrCorrupt   EntryError = "corrupt"
+)
+
+func unsupportedMethod(m uint64) EntryError {
+	return EntryError(fmt.Sprintf("unsupported method (%d)", m))
+}
+
 type Decompressor interface {
 	Next() error // when finished, should return io.EOF
 	Reader() io.Reader
@@ -61,18 +83,83 @@ type Decompressor interface {
 	Dirs() []string
 }
 
+// Source is the content of an archive, provided to a Constructor. It can be read sequentially or at any offset.
+type Source interface {
+	io.Reader
+	io.ReaderAt
+}
+
+// Constructor returns a Decompressor for an archive of sz bytes. Path is the archive's path,
+// to which the paths of its contents should be joined with Arcpath.
+type Constructor func(src Source, sz int64, path string) (Decompressor, error)
+
+var constructors = make(map[config.Archive]Constructor)
+
+// Register allows external packages to add decompressors for formats with any of the given
+// ids (PUIDs, MIME types, LOC or Wikidata IDs). The name selects the format for decompression
+// (e.g. with sf's -zs flag) and is included in config.ListAllArcTypes. Register should be
+// called before identification begins, e.g. in an init function.
+func Register(name string, c Constructor, ids ...string) config.Archive {
+	arc := config.RegisterArchive(name, ids...)
+	constructors[arc] = c
+	return arc
+}
+
 func New(arc config.Archive, buf *siegreader.Buffer, path string) (Decompressor, error) {
+	return newDecompressor(arc, buf, path, filepath.Base(path))
+}
+
+// NewEntry is like New, for an archive that is itself an entry within the archive at parent
+// (i.e. path was made with Arcpath). The contents of compressed streams, which don't record
+// their own names, are named after the entry rather than the full path.
+func NewEntry(arc config.Archive, buf *siegreader.Buffer, path, parent string) (Decompressor, error) {
+	name := filepath.Base(path)
+	if prefix := Arcpath(parent, ""); strings.HasPrefix(path, prefix) {
+		name = filepath.Base(strings.TrimPrefix(path, prefix))
+	}
+	return newDecompressor(arc, buf, path, name)
+}
+
+// name is the base name of the archive, used to name the contents of compressed streams
+func newDecompressor(arc config.Archive, buf *siegreader.Buffer, path, name string) (Decompressor, error) {
 	switch arc {
 	case config.Zip:
 		return newZip(buf, path)
 	case config.Gzip:
-		return newGzip(buf, path)
+		return newGzip(buf, path, name)
 	case config.Tar:
 		return newTar(siegreader.ReaderFrom(buf), path)
 	case config.ARC:
-		return newARC(siegreader.ReaderFrom(buf), path)
+		return newARC(buf, path)
 	case config.WARC:
-		return newWARC(siegreader.ReaderFrom(buf), path)
+		return newWARC(buf, path)
+	case config.SevenZip:
+		return newSevenZip(buf, path)
+	case config.Bzip2:
+		return newBzip2(buf, path, name)
+	case config.XZ:
+		return newXZ(buf, path, name)
+	case config.Zstd:
+		return newZstd(buf, path, name)
+	case config.ISO:
+		return newISO(buf, path)
+	case config.MBOX:
+		return newMBOX(buf, path)
+	case config.EML:
+		return newEML(buf, path)
+	case config.MSG:
+		return newMSG(buf, path)
+	case config.AR:
+		return newAR(siegreader.ReaderFrom(buf), path)
+	case config.CPIO:
+		return newCPIO(siegreader.ReaderFrom(buf), path)
+	case config.RPM:
+		return newRPM(siegreader.ReaderFrom(buf), path)
+	}
+	if c, ok := constructors[arc]; ok {
+		buf.Quit = make(chan struct{}) // in case a stream with a closed quit channel, make a new one
+		sz := buf.SizeNow()            // in case a stream, force full read
+		return c(siegreader.ReaderFrom(buf), sz, path)
 	}
 	return nil, fmt.Errorf("Decompress: unknown archive type %v", arc)
 }
@@ -101,6 +188,7 @@ func (z *zipD) close() {
 
 func (z *zipD) Next() error {
 	z.close() // close the previous entry, if any
+	z.rc = nil
 	// proceed
 	z.idx++
 	// scan past directories
@@ -109,9 +197,20 @@ func (z *zipD) Next() error {
 	if z.idx >= len(z.rdr.File) {
 		return io.EOF
 	}
+	f := z.rdr.File[z.idx]
+	if f.Flags&0x1 == 0x1 {
+		return ErrEncrypted
+	}
 	var err error
-	z.rc, err = z.rdr.File[z.idx].Open()
-	return err
+	z.rc, err = f.Open()
+	switch err {
+	case nil:
+		return nil
+	case zip.ErrAlgorithm:
+		return unsupportedMethod(uint64(f.Method))
+	}
+	z.rc = nil
+	return ErrCorrupt
 }
 
 func (z *zipD) Reader() io.Reader {
@@ -190,11 +289,12 @@ func (t *tarD) Dirs() []string {
 type gzipD struct {
 	sz   int64
 	p    string
+	name string // name of the archive, to derive a name for the contents if the header doesn't give one
 	read bool
 	rdr  *gzip.Reader
 }
 
-func newGzip(b *siegreader.Buffer, path string) (Decompressor, error) {
+func newGzip(b *siegreader.Buffer, path, name string) (Decompressor, error) {
 	b.Quit = make(chan struct{}) // in case a stream with a closed quit channel, make a new one
 	_ = b.SizeNow()              // in case a stream, force full read
 	buf, err := b.EofSlice(0, 4) // gzip stores uncompressed size in last 4 bytes of the stream
@@ -203,7 +303,7 @@ func newGzip(b *siegreader.Buffer, path string) (Decompressor, error) {
 	}
 	sz := int64(uint32(buf[0]) | uint32(buf[1])<<8 | uint32(buf[2])<<16 | uint32(buf[3])<<24)
 	g, err := gzip.NewReader(siegreader.ReaderFrom(b))
-	return &gzipD{sz: sz, p: path, rdr: g}, err
+	return &gzipD{sz: sz, p: path, name: name, rdr: g}, err
 }
 
 func (g *gzipD) Next() error {
@@ -222,12 +322,7 @@ func (g *gzipD) Reader() io.Reader {
 func (g *gzipD) Path() string {
 	name := g.rdr.Name
 	if len(name) == 0 {
-		switch filepath.Ext(g.p) {
-		case ".gz", ".z", ".gzip", ".zip":
-			name = strings.TrimSuffix(filepath.Base(g.p), filepath.Ext(g.p))
-		default:
-			name = filepath.Base(g.p)
-		}
+		name = trimExt(g.name, ".gz", ".z", ".gzip", ".zip")
 	}
 	return Arcpath(g.p, name)
 }
@@ -248,6 +343,100 @@ func (t *gzipD) Dirs() []string {
 	return nil
 }
 
+// streamD decompresses single stream compression formats that, unlike gzip,
+// don't record a name, modified time or size for their contents
+type streamD struct {
+	p     string
+	name  string   // name of the archive
+	exts  []string // extensions to trim from the name to derive a name for the contents
+	read  bool
+	rdr   io.Reader
+	close func()
+}
+
+func newBzip2(b *siegreader.Buffer, path, name string) (Decompressor, error) {
+	b.Quit = make(chan struct{}) // in case a stream with a closed quit channel, make a new one
+	return &streamD{
+		p:    path,
+		name: name,
+		exts: []string{".bz2", ".bz", ".bzip2"},
+		rdr:  bzip2.NewReader(siegreader.ReaderFrom(b)),
+	}, nil
+}
+
+func newXZ(b *siegreader.Buffer, path, name string) (Decompressor, error) {
+	b.Quit = make(chan struct{}) // in case a stream with a closed quit channel, make a new one
+	x, err := xz.NewReader(siegreader.ReaderFrom(b))
+	return &streamD{
+		p:    path,
+		name: name,
+		exts: []string{".xz"},
+		rdr:  x,
+	}, err
+}
+
+func newZstd(b *siegreader.Buffer, path, name string) (Decompressor, error) {
+	b.Quit = make(chan struct{}) // in case a stream with a closed quit channel, make a new one
+	z, err := zstd.NewReader(siegreader.ReaderFrom(b), zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &streamD{
+		p:     path,
+		name:  name,
+		exts:  []string{".zst", ".zstd"},
+		rdr:   z,
+		close: z.Close,
+	}, nil
+}
+
+func (s *streamD) Next() error {
+	if s.read {
+		if s.close != nil {
+			s.close()
+		}
+		return io.EOF
+	}
+	s.read = true
+	return nil
+}
+
+func (s *streamD) Reader() io.Reader {
+	return s.rdr
+}
+
+func (s *streamD) Path() string {
+	return Arcpath(s.p, trimExt(s.name, s.exts...))
+}
+
+func (s *streamD) MIME() string {
+	return ""
+}
+
+// Size returns -1 as the size of the contents isn't known until they have been read
+func (s *streamD) Size() int64 {
+	return -1
+}
+
+func (s *streamD) Mod() time.Time {
+	return time.Time{}
+}
+
+func (s *streamD) Dirs() []string {
+	return nil
+}
+
+// trimExt returns the base of a path, less its extension if it matches one of exts
+func trimExt(p string, exts ...string) string {
+	base, ext := filepath.Base(p), filepath.Ext(p)
+	for _, e := range exts {
+		if ext == e {
+			return strings.TrimSuffix(base, ext)
+		}
+	}
+	return base
+}
+
 func trimWebPath(p string) string {
 	d, f := filepath.Split(p)
 	clean := strings.TrimSuffix(d, string(filepath.Separator))
@@ -259,29 +448,37 @@ func trimWebPath(p string) string {
 }
 
 type wa struct {
-	p   string
-	rec webarchive.Record
-	rdr webarchive.Reader
+	p    string
+	rec  webarchive.Record
+	rdr  webarchive.Reader
+	pl   webarchive.Record // the record with any encodings decoded
+	hdrs *headerScanner    // for the fields webarchive doesn't report (see Fields)
+	hdr  recordHeader
 }
 
-func newARC(r io.Reader, path string) (Decompressor, error) {
-	arcReader, err := webarchive.NewARCReader(r)
-	return &wa{p: trimWebPath(path), rdr: arcReader}, err
+func newARC(b *siegreader.Buffer, path string) (Decompressor, error) {
+	arcReader, err := webarchive.NewARCReader(siegreader.ReaderFrom(b))
+	return &wa{p: trimWebPath(path), rdr: arcReader, hdrs: newHeaderScanner(siegreader.ReaderFrom(b), true)}, err
 }
 
-func newWARC(r io.Reader, path string) (Decompressor, error) {
-	warcReader, err := webarchive.NewWARCReader(r)
-	return &wa{p: trimWebPath(path), rdr: warcReader}, err
+func newWARC(b *siegreader.Buffer, path string) (Decompressor, error) {
+	warcReader, err := webarchive.NewWARCReader(siegreader.ReaderFrom(b))
+	return &wa{p: trimWebPath(path), rdr: warcReader, hdrs: newHeaderScanner(siegreader.ReaderFrom(b), false)}, err
 }
 
 func (w *wa) Next() error {
 	var err error
 	w.rec, err = w.rdr.NextPayload()
-	return err
+	if err != nil {
+		return err
+	}
+	w.pl = webarchive.DecodePayload(w.rec)
+	w.hdr = w.hdrs.next(w.rec)
+	return nil
 }
 
 func (w *wa) Reader() io.Reader {
-	return webarchive.DecodePayload(w.rec)
+	return w.pl
 }
 
 func (w *wa) Path() string {
@@ -0,0 +1,192 @@
+package decompress
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+func TestTrimExt(t *testing.T) {
+	tests := [][3]string{
+		{"dir/file.tar.bz2", "file.tar", ".bz2"},
+		{"file.tar.xz", "file.tar", ".xz"},
+		{"file.tzst", "file.tzst", ".zst"},
+		{"file", "file", ".gz"},
+		{"/data/issue#42.gz", "issue#42", ".gz"},
+	}
+	for _, v := range tests {
+		if got := trimExt(v[0], v[2]); got != v[1] {
+			t.Errorf("trimExt(%s): expecting %s, got %s", v[0], v[1], got)
+		}
+	}
+}
+
+func TestStreams(t *testing.T) {
+	content := []byte("hello world")
+	var xzb, zstdb bytes.Buffer
+	xw, err := xz.NewWriter(&xzb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xw.Write(content)
+	xw.Close()
+	zw, err := zstd.NewWriter(&zstdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Write(content)
+	zw.Close()
+	bufs := siegreader.New()
+	for _, v := range []struct {
+		arc    config.Archive
+		path   string
+		parent string // if an entry within an archive
+		name   string
+		byt    []byte
+	}{
+		{config.XZ, "hello.txt.xz", "", "hello.txt", xzb.Bytes()},
+		{config.Zstd, "hello.txt.zst", "", "hello.txt", zstdb.Bytes()},
+		{config.XZ, "issue#42.txt.xz", "", "issue#42.txt", xzb.Bytes()},
+		{config.XZ, Arcpath("package.deb", "data.tar.xz"), "package.deb", "data.tar", xzb.Bytes()},
+	} {
+		buf, err := bufs.Get(bytes.NewReader(v.byt))
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		var d Decompressor
+		if v.parent == "" {
+			d, err = New(v.arc, buf, v.path)
+		} else {
+			d, err = NewEntry(v.arc, buf, v.path, v.parent)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", v.arc, err)
+		}
+		if err = d.Next(); err != nil {
+			t.Fatalf("%s: %v", v.arc, err)
+		}
+		if expect := Arcpath(v.path, v.name); d.Path() != expect {
+			t.Errorf("%s: expecting path %s, got %s", v.arc, expect, d.Path())
+		}
+		got, err := io.ReadAll(d.Reader())
+		if err != nil || !bytes.Equal(got, content) {
+			t.Errorf("%s: expecting %s, got %s (%v)", v.arc, content, got, err)
+		}
+		if err = d.Next(); err != io.EOF {
+			t.Errorf("%s: expecting EOF, got %v", v.arc, err)
+		}
+		bufs.Put(buf)
+	}
+}
+
+// linesD is a registered test format: each line of the archive is an entry
+type linesD struct {
+	p     string
+	lines []string
+	idx   int
+}
+
+func (l *linesD) Next() error {
+	if l.idx >= len(l.lines) {
+		return io.EOF
+	}
+	l.idx++
+	return nil
+}
+
+func (l *linesD) Reader() io.Reader { return strings.NewReader(l.lines[l.idx-1]) }
+func (l *linesD) Path() string      { return Arcpath(l.p, strconv.Itoa(l.idx)) }
+func (l *linesD) MIME() string      { return "" }
+func (l *linesD) Size() int64       { return int64(len(l.lines[l.idx-1])) }
+func (l *linesD) Mod() time.Time    { return time.Time{} }
+func (l *linesD) Dirs() []string    { return nil }
+
+func TestRegister(t *testing.T) {
+	arc := Register("lines", func(src Source, sz int64, path string) (Decompressor, error) {
+		byt := make([]byte, sz)
+		if _, err := src.ReadAt(byt, 0); err != nil && err != io.EOF {
+			return nil, err
+		}
+		return &linesD{p: path, lines: strings.Split(string(byt), "\n")}, nil
+	}, "application/x-lines")
+	config.SetArchiveFilterPermissive("lines")
+	defer config.SetArchiveFilterPermissive("")
+	if got := config.IsArchive("application/x-lines"); got != arc {
+		t.Fatalf("expecting registered archive %v, got %v", arc, got)
+	}
+	bufs := siegreader.New()
+	buf, err := bufs.Get(strings.NewReader("one\ntwo"))
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	defer bufs.Put(buf)
+	d, err := New(arc, buf, "test.lines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for err = d.Next(); err == nil; err = d.Next() {
+		byt, _ := io.ReadAll(d.Reader())
+		got = append(got, d.Path()+":"+string(byt))
+	}
+	if err != io.EOF || strings.Join(got, ",") != "test.lines#1:one,test.lines#2:two" {
+		t.Errorf("unexpected registered decompressor results: %v (%v)", got, err)
+	}
+}
+
+// makeBadZip returns a zip with an encrypted entry, an entry with an unsupported compression method,
+// an entry with a corrupt local header and a good entry
+func makeBadZip(t *testing.T) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, h := range []*zip.FileHeader{
+		{Name: "encrypted.txt", Flags: 0x1},
+		{Name: "lzma.txt", Method: 14},
+		{Name: "corrupt.txt"},
+	} {
+		w, err := zw.CreateRaw(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("junk"))
+	}
+	w, _ := zw.Create("good.txt")
+	w.Write([]byte("hello world"))
+	zw.Close()
+	byt := buf.Bytes()
+	// break the local header signature of the third entry
+	var off int
+	for i := 0; i < 3; i++ {
+		off += bytes.Index(byt[off:], []byte("PK\x03\x04")) + 1
+	}
+	byt[off-1] = 'X'
+	return byt
+}
+
+func TestZipEntryErrors(t *testing.T) {
+	bufs := siegreader.New()
+	buf, err := bufs.Get(bytes.NewReader(makeBadZip(t)))
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	defer bufs.Put(buf)
+	d, err := New(config.Zip, buf, "bad.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []error{ErrEncrypted, EntryError("unsupported method (14)"), ErrCorrupt, nil, io.EOF} {
+		if err = d.Next(); err != expect {
+			t.Fatalf("expecting %v, got %v", expect, err)
+		}
+	}
+}
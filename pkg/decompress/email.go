@@ -0,0 +1,272 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decompress
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+// RFC 5322 email messages (EML) and mbox files.
+// Messages are decompressed to their attachments: MIME parts that have a filename,
+// an "attachment" disposition, or that are themselves messages (forwarded email).
+// Mbox files are decompressed to their messages, which are in turn decompressed if EML is selected.
+
+const mimeMessage = "message/rfc822"
+
+var (
+	wordDecoder = &mime.WordDecoder{}
+	safeName    = strings.NewReplacer("/", "_", "\\", "_") // attachment names shouldn't be mistaken for paths
+)
+
+type emlD struct {
+	p      string
+	mod    time.Time
+	single *textproto.MIMEHeader // a message that isn't multipart may itself be an attachment
+	body   io.Reader
+	mps    []*multipart.Reader // stack of nested multipart bodies
+	n      int                 // count of attachments
+	name   string
+	mime   string
+	rdr    io.Reader
+}
+
+func newEML(b *siegreader.Buffer, path string) (Decompressor, error) {
+	b.Quit = make(chan struct{}) // in case a stream with a closed quit channel, make a new one
+	msg, err := mail.ReadMessage(bufio.NewReader(siegreader.ReaderFrom(b)))
+	if err != nil {
+		return nil, err
+	}
+	e := &emlD{p: path}
+	e.mod, _ = msg.Header.Date()
+	hdr := textproto.MIMEHeader(msg.Header)
+	if boundary, ok := multipartBoundary(hdr); ok {
+		e.mps = []*multipart.Reader{multipart.NewReader(msg.Body, boundary)}
+	} else {
+		e.single, e.body = &hdr, msg.Body
+	}
+	return e, nil
+}
+
+func multipartBoundary(hdr textproto.MIMEHeader) (string, bool) {
+	mt, params, err := mime.ParseMediaType(hdr.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mt, "multipart/") || params["boundary"] == "" {
+		return "", false
+	}
+	return params["boundary"], true
+}
+
+func (e *emlD) Next() error {
+	if e.single != nil {
+		hdr := *e.single
+		e.single = nil
+		if e.attachment(hdr, e.body) {
+			return nil
+		}
+		return io.EOF
+	}
+	for len(e.mps) > 0 {
+		part, err := e.mps[len(e.mps)-1].NextRawPart()
+		if err == io.EOF {
+			e.mps = e.mps[:len(e.mps)-1]
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if boundary, ok := multipartBoundary(part.Header); ok {
+			e.mps = append(e.mps, multipart.NewReader(part, boundary))
+			continue
+		}
+		if e.attachment(part.Header, part) {
+			return nil
+		}
+	}
+	return io.EOF
+}
+
+// attachment sets the name, MIME and reader for a part if it is an attachment
+func (e *emlD) attachment(hdr textproto.MIMEHeader, r io.Reader) bool {
+	mt, params, _ := mime.ParseMediaType(hdr.Get("Content-Type"))
+	disp, dparams, _ := mime.ParseMediaType(hdr.Get("Content-Disposition"))
+	name := dparams["filename"]
+	if name == "" {
+		name = params["name"]
+	}
+	if name == "" && disp != "attachment" && mt != mimeMessage {
+		return false
+	}
+	e.n++
+	if name == "" {
+		name = fmt.Sprintf("attachment-%d", e.n)
+		if mt == mimeMessage {
+			name += ".eml"
+		}
+	} else if dec, err := wordDecoder.DecodeHeader(name); err == nil {
+		name = dec
+	}
+	e.name = safeName.Replace(name)
+	e.mime = mt
+	switch strings.ToLower(strings.TrimSpace(hdr.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		e.rdr = base64.NewDecoder(base64.StdEncoding, r) // the decoder skips line breaks
+	case "quoted-printable":
+		e.rdr = quotedprintable.NewReader(r)
+	default:
+		e.rdr = r
+	}
+	return true
+}
+
+func (e *emlD) Reader() io.Reader {
+	return e.rdr
+}
+
+func (e *emlD) Path() string {
+	return Arcpath(e.p, e.name)
+}
+
+func (e *emlD) MIME() string {
+	return e.mime
+}
+
+// Size returns -1 as the size of an attachment isn't known until it has been decoded
+func (e *emlD) Size() int64 {
+	return -1
+}
+
+// Mod returns the date of the message
+func (e *emlD) Mod() time.Time {
+	return e.mod
+}
+
+func (e *emlD) Dirs() []string {
+	return nil
+}
+
+type mboxD struct {
+	p   string
+	rdr *bufio.Reader
+	n   int
+	mod time.Time
+	msg *mboxMsg
+}
+
+func newMBOX(b *siegreader.Buffer, path string) (Decompressor, error) {
+	b.Quit = make(chan struct{}) // in case a stream with a closed quit channel, make a new one
+	return &mboxD{p: path, rdr: bufio.NewReader(siegreader.ReaderFrom(b))}, nil
+}
+
+func (m *mboxD) Next() error {
+	if m.msg != nil {
+		if _, err := io.Copy(io.Discard, m.msg); err != nil {
+			return err
+		}
+	}
+	// find the next "From " line, skipping blank lines
+	for {
+		line, err := m.rdr.ReadString('\n')
+		if strings.HasPrefix(line, "From ") {
+			m.n++
+			m.mod = mboxTime(line)
+			m.msg = &mboxMsg{rdr: m.rdr}
+			return nil
+		}
+		if err != nil {
+			if err == io.EOF {
+				return io.EOF
+			}
+			return err
+		}
+		if strings.TrimSpace(line) != "" {
+			return fmt.Errorf("mbox: expecting a From line, got %q", line)
+		}
+	}
+}
+
+// the From line ends with an asctime date e.g. "From user@example.com Mon Jan  2 15:04:05 2006"
+func mboxTime(line string) time.Time {
+	line = strings.TrimSpace(line)
+	if len(line) < len(time.ANSIC) {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.ANSIC, line[len(line)-len(time.ANSIC):])
+	return t
+}
+
+func (m *mboxD) Reader() io.Reader {
+	return m.msg
+}
+
+func (m *mboxD) Path() string {
+	return Arcpath(m.p, fmt.Sprintf("message-%d.eml", m.n))
+}
+
+func (m *mboxD) MIME() string {
+	return mimeMessage
+}
+
+func (m *mboxD) Size() int64 {
+	return -1
+}
+
+func (m *mboxD) Mod() time.Time {
+	return m.mod
+}
+
+func (m *mboxD) Dirs() []string {
+	return nil
+}
+
+// mboxMsg reads a message up to the next "From " line, unquoting ">From " lines (mboxrd)
+type mboxMsg struct {
+	rdr  *bufio.Reader
+	line []byte // unread remainder of the current line
+	err  error
+}
+
+func (mm *mboxMsg) Read(p []byte) (int, error) {
+	if len(mm.line) == 0 {
+		if mm.err != nil {
+			return 0, mm.err
+		}
+		if peek, _ := mm.rdr.Peek(5); string(peek) == "From " {
+			mm.err = io.EOF
+			return 0, io.EOF
+		}
+		mm.line, mm.err = mm.rdr.ReadBytes('\n')
+		if len(mm.line) > 1 && mm.line[0] == '>' && bytes.HasPrefix(bytes.TrimLeft(mm.line, ">"), []byte("From ")) {
+			mm.line = mm.line[1:]
+		}
+		if len(mm.line) == 0 {
+			return 0, mm.err
+		}
+	}
+	n := copy(p, mm.line)
+	mm.line = mm.line[n:]
+	return n, nil
+}
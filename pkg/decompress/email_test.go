@@ -0,0 +1,154 @@
+package decompress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+const testEML = "From: a@example.com\r\n" +
+	"To: b@example.com\r\n" +
+	"Subject: reports\r\n" +
+	"Date: Mon, 01 Jan 2024 10:00:00 +0000\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"inner\"\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"\r\n" +
+	"body text\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html\r\n" +
+	"\r\n" +
+	"<p>body text</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf; name=\"report.pdf\"\r\n" +
+	"Content-Disposition: attachment; filename=\"=?utf-8?q?r=C3=A9sum=C3=A9.pdf?=\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0xLjQK\r\n" +
+	"JSVFT0YK\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/csv; name=\"a/b.csv\"\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"a,b=\r\n" +
+	",c=3D\r\n" +
+	"--outer\r\n" +
+	"Content-Type: message/rfc822\r\n" +
+	"\r\n" +
+	"Subject: forwarded\r\n" +
+	"\r\n" +
+	"hi\r\n" +
+	"--outer--\r\n"
+
+func TestEML(t *testing.T) {
+	bufs := siegreader.New()
+	buf, err := bufs.Get(strings.NewReader(testEML))
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	defer bufs.Put(buf)
+	d, err := New(config.EML, buf, "test.eml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []struct {
+		name, mime, content string
+	}{
+		{"résumé.pdf", "application/pdf", "%PDF-1.4\n%%EOF\n"},
+		{"a_b.csv", "text/csv", "a,b,c="},
+		{"attachment-3.eml", "message/rfc822", "Subject: forwarded\r\n\r\nhi"},
+	}
+	var i int
+	for err = d.Next(); err == nil; err = d.Next() {
+		if i >= len(expect) {
+			t.Fatalf("unexpected attachment %s", d.Path())
+		}
+		byt, err := io.ReadAll(d.Reader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Path() != Arcpath("test.eml", expect[i].name) || d.MIME() != expect[i].mime || string(byt) != expect[i].content {
+			t.Errorf("expecting %v, got %s %s %q", expect[i], d.Path(), d.MIME(), byt)
+		}
+		if !d.Mod().Equal(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected mod time %v", d.Mod())
+		}
+		i++
+	}
+	if err != io.EOF {
+		t.Fatal(err)
+	}
+	if i != len(expect) {
+		t.Errorf("expecting %d attachments, got %d", len(expect), i)
+	}
+}
+
+func TestMBOX(t *testing.T) {
+	mbox := "From a@example.com Mon Jan  1 10:00:00 2024\n" +
+		"Subject: one\n\n>From here\n>>From there\nFrom\n\n" +
+		"From b@example.com Tue Jan  2 11:00:00 2024\n" +
+		"Subject: two\n\nbye\n"
+	bufs := siegreader.New()
+	buf, err := bufs.Get(strings.NewReader(mbox))
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	defer bufs.Put(buf)
+	d, err := New(config.MBOX, buf, "test.mbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []struct {
+		content string
+		mod     time.Time
+	}{
+		{"Subject: one\n\nFrom here\n>From there\nFrom\n\n", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{"Subject: two\n\nbye\n", time.Date(2024, 1, 2, 11, 0, 0, 0, time.UTC)},
+	}
+	var i int
+	for err = d.Next(); err == nil; err = d.Next() {
+		if i >= len(expect) {
+			t.Fatalf("unexpected message %s", d.Path())
+		}
+		// read the first message in small chunks, and skip reading the second
+		if i == 0 {
+			var got bytes.Buffer
+			p := make([]byte, 3)
+			for {
+				n, err := d.Reader().Read(p)
+				got.Write(p[:n])
+				if err != nil {
+					break
+				}
+			}
+			if got.String() != expect[i].content {
+				t.Errorf("expecting %q, got %q", expect[i].content, got.String())
+			}
+		}
+		if d.MIME() != "message/rfc822" || !d.Mod().Equal(expect[i].mod) {
+			t.Errorf("unexpected MIME or mod time: %s %v", d.MIME(), d.Mod())
+		}
+		i++
+		if d.Path() != Arcpath("test.mbox", fmt.Sprintf("message-%d.eml", i)) {
+			t.Errorf("unexpected path %s", d.Path())
+		}
+	}
+	if err != io.EOF {
+		t.Fatal(err)
+	}
+	if i != len(expect) {
+		t.Errorf("expecting %d messages, got %d", len(expect), i)
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decompress
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+// ISO 9660 (ECMA-119) disc images, with Joliet and Rock Ridge (IEEE P1282) extensions for long file names.
+// UDF-only images are not supported.
+
+const (
+	isoSector    = 2048
+	isoFirstVD   = 16  // volume descriptors begin after the system area
+	isoMaxVD     = 64  // give up looking for a terminator after this many descriptors
+	isoMaxDepth  = 255 // Rock Ridge permits deep directory trees; this guards against loops
+	isoRecordLen = 33  // length of a directory record, excluding the file identifier and system use area
+)
+
+var errISO = errors.New("iso: corrupt directory record")
+
+// how file identifiers are decoded
+type isoNames int
+
+const (
+	isoPlain isoNames = iota
+	isoJoliet
+	isoRockRidge
+)
+
+type isoD struct {
+	p       string
+	ra      io.ReaderAt
+	sz      int64
+	bs      int64 // logical block size
+	names   isoNames
+	skip    int // bytes to skip at the start of each system use area (given by the SUSP SP entry)
+	files   []isoFile
+	visited map[int64]bool
+	idx     int
+	written map[string]bool
+}
+
+type isoFile struct {
+	name    string
+	extents [][2]int64 // offset and length; files larger than 4GB are recorded in multiple extents
+	size    int64
+	mod     time.Time
+}
+
+func newISO(b *siegreader.Buffer, path string) (Decompressor, error) {
+	b.Quit = make(chan struct{}) // in case a stream with a closed quit channel, make a new one
+	sz := b.SizeNow()            // in case a stream, force full read
+	i := &isoD{p: path, ra: siegreader.ReaderFrom(b), sz: sz, bs: isoSector, idx: -1, visited: make(map[int64]bool)}
+	var pvd, svd []byte
+	for s := int64(isoFirstVD); ; s++ {
+		if s >= isoFirstVD+isoMaxVD {
+			return nil, errors.New("iso: no volume descriptor set terminator")
+		}
+		vd, err := i.read(s*isoSector, isoSector)
+		if err != nil {
+			return nil, err
+		}
+		if string(vd[1:6]) != "CD001" {
+			return nil, errors.New("iso: bad volume descriptor")
+		}
+		if vd[0] == 255 {
+			break
+		}
+		switch vd[0] {
+		case 1:
+			if pvd == nil {
+				pvd = vd
+			}
+		case 2:
+			// a supplementary descriptor is Joliet if it has a UCS-2 escape sequence
+			if svd == nil && vd[88] == '%' && vd[89] == '/' && (vd[90] == '@' || vd[90] == 'C' || vd[90] == 'E') {
+				svd = vd
+			}
+		}
+	}
+	if pvd == nil {
+		return nil, errors.New("iso: no primary volume descriptor")
+	}
+	if bs := int64(binary.LittleEndian.Uint16(pvd[128:])); bs == 512 || bs == 1024 {
+		i.bs = bs
+	}
+	// prefer Rock Ridge names, then Joliet, then plain ISO 9660 names
+	root := pvd[156 : 156+34]
+	ok, err := i.rockRidge(root)
+	switch {
+	case err != nil:
+		return nil, err
+	case ok:
+		i.names = isoRockRidge
+	case svd != nil:
+		i.names = isoJoliet
+		root = svd[156 : 156+34]
+	}
+	return i, i.walk(root, "", 0)
+}
+
+func (i *isoD) read(off, l int64) ([]byte, error) {
+	if off < 0 || l < 0 || off+l > i.sz {
+		return nil, errors.New("iso: extent out of bounds")
+	}
+	buf := make([]byte, l)
+	_, err := i.ra.ReadAt(buf, off)
+	if err == io.EOF {
+		err = nil
+	}
+	return buf, err
+}
+
+// the first record in the root directory (".") has a SUSP SP entry if Rock Ridge extensions are used
+func (i *isoD) rockRidge(root []byte) (bool, error) {
+	data, err := i.read(int64(binary.LittleEndian.Uint32(root[2:]))*i.bs, isoSector)
+	if err != nil {
+		return false, err
+	}
+	if int(data[0]) < isoRecordLen+8 {
+		return false, nil
+	}
+	su := data[isoRecordLen+1 : data[0]]
+	if su[0] == 'S' && su[1] == 'P' && su[4] == 0xBE && su[5] == 0xEF {
+		i.skip = int(su[6])
+		return true, nil
+	}
+	return false, nil
+}
+
+func (i *isoD) walk(rec []byte, dir string, depth int) error {
+	loc := int64(binary.LittleEndian.Uint32(rec[2:])) * i.bs
+	if depth > isoMaxDepth || i.visited[loc] {
+		return nil
+	}
+	i.visited[loc] = true
+	data, err := i.read(loc, int64(binary.LittleEndian.Uint32(rec[10:])))
+	if err != nil {
+		return err
+	}
+	multi := -1 // index of a file with outstanding extents
+	for off := 0; off < len(data); {
+		l := int(data[off])
+		if l == 0 { // records don't cross sector boundaries; zero padding fills the remainder
+			off = (off/isoSector + 1) * isoSector
+			continue
+		}
+		if l < isoRecordLen+1 || off+l > len(data) || isoRecordLen+int(data[off+32]) > l {
+			return errISO
+		}
+		r := data[off : off+l]
+		off += l
+		nl := int(r[32])
+		id := r[isoRecordLen : isoRecordLen+nl]
+		if nl == 1 && id[0] <= 1 { // "." and ".."
+			continue
+		}
+		name, isDir, ext := i.name(r, id)
+		if name == "" { // a relocated directory; it is reached through its child link instead
+			continue
+		}
+		name = path.Join(dir, name)
+		if isDir {
+			multi = -1
+			if err := i.walk(ext, name, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		extent := [2]int64{int64(binary.LittleEndian.Uint32(r[2:])) * i.bs, int64(binary.LittleEndian.Uint32(r[10:]))}
+		if multi < 0 {
+			i.files = append(i.files, isoFile{name: name, mod: isoTime(r[18:25])})
+			multi = len(i.files) - 1
+		}
+		i.files[multi].extents = append(i.files[multi].extents, extent)
+		i.files[multi].size += extent[1]
+		if r[25]&0x80 == 0 { // the final extent doesn't have the multi-extent flag
+			multi = -1
+		}
+	}
+	return nil
+}
+
+// name returns a decoded name for a directory record, whether it is a directory, and
+// the record to use for the directory's extent (this differs for relocated Rock Ridge directories)
+func (i *isoD) name(r, id []byte) (string, bool, []byte) {
+	isDir := r[25]&0x02 == 0x02
+	switch i.names {
+	case isoJoliet:
+		u := make([]uint16, len(id)/2)
+		for j := range u {
+			u[j] = binary.BigEndian.Uint16(id[j*2:])
+		}
+		return trimVersion(string(utf16.Decode(u))), isDir, r
+	case isoRockRidge:
+		su := r[isoRecordLen+len(id):]
+		if len(id)%2 == 0 && len(su) > 0 {
+			su = su[1:] // padding byte
+		}
+		var nm []byte
+		var ok bool
+		ext := r
+		for _, e := range i.susp(su) {
+			switch string(e[:2]) {
+			case "NM":
+				if len(e) > 5 && e[4]&0x06 == 0 { // ignore "." and ".." flags
+					nm = append(nm, e[5:]...)
+					ok = true
+				}
+			case "RE":
+				return "", isDir, r
+			case "CL":
+				if len(e) >= 12 {
+					// the child link points to the relocated directory's "." record
+					if cr, err := i.read(int64(binary.LittleEndian.Uint32(e[4:]))*i.bs, isoRecordLen+1); err == nil {
+						isDir, ext = true, cr
+					}
+				}
+			}
+		}
+		if ok {
+			return string(nm), isDir, ext
+		}
+		return trimVersion(string(id)), isDir, ext
+	}
+	return trimVersion(string(id)), isDir, r
+}
+
+// susp returns the entries in a system use area, following any continuation areas
+func (i *isoD) susp(su []byte) [][]byte {
+	var ret [][]byte
+	if len(su) < i.skip {
+		return nil
+	}
+	su = su[i.skip:]
+	for n := 0; n < 16; n++ { // limit the number of continuation areas
+		var ce []byte
+		for len(su) >= 4 {
+			l := int(su[2])
+			if l < 4 || l > len(su) {
+				break
+			}
+			e := su[:l]
+			su = su[l:]
+			switch string(e[:2]) {
+			case "ST":
+				su = nil
+			case "CE":
+				ce = e
+			default:
+				ret = append(ret, e)
+			}
+		}
+		if len(ce) < 28 {
+			return ret
+		}
+		var err error
+		su, err = i.read(int64(binary.LittleEndian.Uint32(ce[4:]))*i.bs+int64(binary.LittleEndian.Uint32(ce[12:])), int64(binary.LittleEndian.Uint32(ce[20:])))
+		if err != nil {
+			return ret
+		}
+	}
+	return ret
+}
+
+// strip the ";1" version suffix and the trailing "." of names without extensions
+func trimVersion(s string) string {
+	if idx := strings.LastIndexByte(s, ';'); idx > 0 {
+		s = s[:idx]
+	}
+	if len(s) > 1 {
+		s = strings.TrimSuffix(s, ".")
+	}
+	return s
+}
+
+// recording dates are years since 1900, month, day, hour, minute, second and GMT offset in 15 minute intervals
+func isoTime(b []byte) time.Time {
+	if b[0] == 0 && b[1] == 0 && b[2] == 0 {
+		return time.Time{}
+	}
+	return time.Date(1900+int(b[0]), time.Month(b[1]), int(b[2]), int(b[3]), int(b[4]), int(b[5]), 0, time.FixedZone("", int(int8(b[6]))*15*60))
+}
+
+func (i *isoD) Next() error {
+	i.idx++
+	if i.idx >= len(i.files) {
+		return io.EOF
+	}
+	return nil
+}
+
+func (i *isoD) Reader() io.Reader {
+	f := i.files[i.idx]
+	if len(f.extents) == 1 {
+		return io.NewSectionReader(i.ra, f.extents[0][0], f.extents[0][1])
+	}
+	rdrs := make([]io.Reader, len(f.extents))
+	for j, e := range f.extents {
+		rdrs[j] = io.NewSectionReader(i.ra, e[0], e[1])
+	}
+	return io.MultiReader(rdrs...)
+}
+
+func (i *isoD) Path() string {
+	return Arcpath(i.p, filepath.FromSlash(i.files[i.idx].name))
+}
+
+func (i *isoD) MIME() string {
+	return ""
+}
+
+func (i *isoD) Size() int64 {
+	return i.files[i.idx].size
+}
+
+func (i *isoD) Mod() time.Time {
+	return i.files[i.idx].mod
+}
+
+func (i *isoD) Dirs() []string {
+	if i.written == nil {
+		i.written = make(map[string]bool)
+	}
+	return dirs(i.p, i.files[i.idx].name, i.written)
+}
@@ -0,0 +1,163 @@
+package decompress
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+var isoMod = time.Date(2021, 5, 6, 7, 8, 9, 0, time.UTC)
+
+// isoRec makes a directory record
+func isoRec(loc, sz uint32, dir bool, id, su []byte) []byte {
+	l := isoRecordLen + len(id)
+	if len(id)%2 == 0 {
+		l++
+	}
+	l += len(su)
+	if l%2 == 1 {
+		l++
+	}
+	r := make([]byte, l)
+	r[0] = byte(l)
+	binary.LittleEndian.PutUint32(r[2:], loc)
+	binary.BigEndian.PutUint32(r[6:], loc)
+	binary.LittleEndian.PutUint32(r[10:], sz)
+	binary.BigEndian.PutUint32(r[14:], sz)
+	copy(r[18:], []byte{byte(isoMod.Year() - 1900), byte(isoMod.Month()), byte(isoMod.Day()), byte(isoMod.Hour()), byte(isoMod.Minute()), byte(isoMod.Second()), 0})
+	if dir {
+		r[25] = 0x02
+	}
+	r[32] = byte(len(id))
+	copy(r[isoRecordLen:], id)
+	if len(su) > 0 {
+		copy(r[isoRecordLen+len(id)+(1-len(id)%2):], su)
+	}
+	return r
+}
+
+func isoNM(name string) []byte {
+	return append([]byte{'N', 'M', byte(5 + len(name)), 1, 0}, name...)
+}
+
+func isoUCS2(s string) []byte {
+	var b []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(u>>8), byte(u))
+	}
+	return b
+}
+
+// makeISO builds an image with a README file in the root and a HELLO file in a DOCS directory.
+// Sectors are: 16 PVD, 17 Joliet SVD, 18 terminator, 19 root, 20 docs, 21 Joliet root, 22 Joliet docs, 23 & 24 file data.
+func makeISO(joliet, rr bool) []byte {
+	img := make([]byte, 25*isoSector)
+	sector := func(n int) []byte { return img[n*isoSector : (n+1)*isoSector] }
+	copy(sector(23), "hello world")
+	copy(sector(24), "%PDF-1.4\n%%EOF\n")
+	vd := func(n int, typ byte, root uint32) {
+		s := sector(n)
+		s[0] = typ
+		copy(s[1:], "CD001")
+		s[6] = 1
+		binary.LittleEndian.PutUint16(s[128:], isoSector)
+		copy(s[156:], isoRec(root, isoSector, true, []byte{0}, nil))
+	}
+	vd(16, 1, 19)
+	if joliet {
+		vd(17, 2, 21)
+		copy(sector(17)[88:], "%/E")
+		vd(18, 255, 0)
+	} else {
+		vd(17, 255, 0)
+	}
+	dir := func(n int, self, parent uint32, recs ...[]byte) {
+		var b bytes.Buffer
+		var su []byte
+		if rr && n == 19 {
+			su = []byte{'S', 'P', 7, 1, 0xBE, 0xEF, 0}
+		}
+		b.Write(isoRec(self, isoSector, true, []byte{0}, su))
+		b.Write(isoRec(parent, isoSector, true, []byte{1}, nil))
+		for _, r := range recs {
+			b.Write(r)
+		}
+		copy(sector(n), b.Bytes())
+	}
+	var rrDocs, rrHello, rrReadme []byte
+	if rr {
+		rrDocs, rrHello, rrReadme = isoNM("Documents"), isoNM("Hello World.txt"), isoNM("read me.pdf")
+	}
+	dir(19, 19, 19,
+		isoRec(20, isoSector, true, []byte("DOCS"), rrDocs),
+		isoRec(24, 15, false, []byte("README.PDF;1"), rrReadme),
+	)
+	dir(20, 20, 19, isoRec(23, 11, false, []byte("HELLO.;1"), rrHello))
+	dir(21, 21, 21,
+		isoRec(22, isoSector, true, isoUCS2("docs"), nil),
+		isoRec(24, 15, false, isoUCS2("readme.pdf;1"), nil),
+	)
+	dir(22, 22, 21, isoRec(23, 11, false, isoUCS2("hello.txt;1"), nil))
+	return img
+}
+
+func TestISO(t *testing.T) {
+	bufs := siegreader.New()
+	for _, tc := range []struct {
+		joliet, rr bool
+		expect     []string
+	}{
+		{false, false, []string{"DOCS/HELLO", "README.PDF"}},
+		{true, false, []string{"docs/hello.txt", "readme.pdf"}},
+		{true, true, []string{"Documents/Hello World.txt", "read me.pdf"}},
+	} {
+		buf, err := bufs.Get(bytes.NewReader(makeISO(tc.joliet, tc.rr)))
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		d, err := New(config.ISO, buf, "test.iso")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		var contents []string
+		var dirs []string
+		for err = d.Next(); err == nil; err = d.Next() {
+			got = append(got, d.Path())
+			byt, _ := io.ReadAll(d.Reader())
+			if int64(len(byt)) != d.Size() {
+				t.Errorf("expecting size %d for %s, got %d", d.Size(), d.Path(), len(byt))
+			}
+			if !d.Mod().Equal(isoMod) {
+				t.Errorf("expecting mod time %v, got %v", isoMod, d.Mod())
+			}
+			contents = append(contents, string(byt))
+			dirs = append(dirs, d.Dirs()...)
+		}
+		if err != io.EOF {
+			t.Fatal(err)
+		}
+		if len(got) != len(tc.expect) {
+			t.Fatalf("expecting %v, got %v", tc.expect, got)
+		}
+		for i, v := range tc.expect {
+			if got[i] != Arcpath("test.iso", filepath.FromSlash(v)) {
+				t.Errorf("expecting %s, got %s", Arcpath("test.iso", filepath.FromSlash(v)), got[i])
+			}
+		}
+		if contents[0] != "hello world" || contents[1] != "%PDF-1.4\n%%EOF\n" {
+			t.Errorf("unexpected contents %v", contents)
+		}
+		if len(dirs) != 1 {
+			t.Errorf("expecting a single directory, got %v", dirs)
+		}
+		bufs.Put(buf)
+	}
+}
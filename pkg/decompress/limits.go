@@ -0,0 +1,123 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decompress
+
+import (
+	"fmt"
+	"io"
+)
+
+// Limits guard against decompression bombs and deeply nested archives.
+// A zero value for any of the limits means that limit isn't applied.
+type Limits struct {
+	Depth   int     // maximum nesting depth e.g. 1 means archives are unpacked, but not archives within archives
+	Ratio   float64 // maximum ratio of bytes unpacked from an archive to the size of that archive
+	Bytes   int64   // maximum bytes unpacked from an archive
+	Entries int     // maximum entries unpacked from an archive
+}
+
+// LimitError is returned when unpacking an archive would exceed one of the Limits.
+type LimitError struct {
+	Limit string // depth, ratio, bytes or entries
+	Max   string
+}
+
+func (le LimitError) Error() string {
+	switch le.Limit {
+	case "depth":
+		return "decompression limit reached: archive not unpacked as nesting depth exceeds " + le.Max
+	case "ratio":
+		return "decompression limit reached: archive not fully unpacked as expansion ratio exceeds " + le.Max
+	case "bytes":
+		return "decompression limit reached: archive not fully unpacked as bytes unpacked exceed " + le.Max
+	}
+	return "decompression limit reached: archive not fully unpacked as entries exceed " + le.Max
+}
+
+// Nested returns a LimitError if an archive at the given depth (0 for files that aren't within an archive) shouldn't be unpacked.
+func (l Limits) Nested(depth int) error {
+	if l.Depth > 0 && depth >= l.Depth {
+		return LimitError{"depth", fmt.Sprint(l.Depth)}
+	}
+	return nil
+}
+
+// Limit wraps a Decompressor so that its Next method, and the readers it provides, return a LimitError once
+// too many entries or bytes have been unpacked. Sz is the size of the archive, used to calculate the expansion ratio.
+func Limit(d Decompressor, l Limits, sz int64) Decompressor {
+	if l.Ratio <= 0 && l.Bytes <= 0 && l.Entries <= 0 {
+		return d
+	}
+	return &limitD{Decompressor: d, l: l, sz: sz}
+}
+
+type limitD struct {
+	Decompressor
+	l       Limits
+	sz      int64
+	entries int
+	read    int64
+	err     error
+}
+
+func (ld *limitD) Next() error {
+	if ld.err != nil {
+		return ld.err
+	}
+	err := ld.Decompressor.Next()
+	if _, ok := err.(EntryError); err != nil && !ok {
+		return err
+	}
+	ld.entries++
+	if ld.l.Entries > 0 && ld.entries > ld.l.Entries {
+		ld.err = LimitError{"entries", fmt.Sprint(ld.l.Entries)}
+		return ld.err
+	}
+	return err
+}
+
+func (ld *limitD) Reader() io.Reader {
+	return &limitReader{ld, ld.Decompressor.Reader()}
+}
+
+// Fields reports the wrapped Decompressor's extra fields, if it has any
+func (ld *limitD) Fields() [][2]string {
+	if f, ok := ld.Decompressor.(Fielder); ok {
+		return f.Fields()
+	}
+	return nil
+}
+
+type limitReader struct {
+	*limitD
+	r io.Reader
+}
+
+func (lr *limitReader) Read(p []byte) (int, error) {
+	if lr.err != nil {
+		return 0, lr.err
+	}
+	n, err := lr.r.Read(p)
+	lr.read += int64(n)
+	switch {
+	case lr.l.Bytes > 0 && lr.read > lr.l.Bytes:
+		lr.err = LimitError{"bytes", fmt.Sprint(lr.l.Bytes)}
+	case lr.l.Ratio > 0 && lr.sz > 0 && float64(lr.read)/float64(lr.sz) > lr.l.Ratio:
+		lr.err = LimitError{"ratio", fmt.Sprint(lr.l.Ratio)}
+	default:
+		return n, err
+	}
+	return n, lr.err
+}
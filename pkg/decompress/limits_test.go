@@ -0,0 +1,78 @@
+package decompress
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeD is a Decompressor with n entries, each of sz bytes
+type fakeD struct {
+	n, sz, idx int
+}
+
+func (f *fakeD) Next() error {
+	if f.idx >= f.n {
+		return io.EOF
+	}
+	f.idx++
+	return nil
+}
+
+func (f *fakeD) Reader() io.Reader { return bytes.NewReader(make([]byte, f.sz)) }
+func (f *fakeD) Path() string      { return "" }
+func (f *fakeD) MIME() string      { return "" }
+func (f *fakeD) Size() int64       { return int64(f.sz) }
+func (f *fakeD) Mod() time.Time    { return time.Time{} }
+func (f *fakeD) Dirs() []string    { return nil }
+
+func TestLimits(t *testing.T) {
+	for _, tc := range []struct {
+		lim     Limits
+		entries int    // entries returned by Next before an error
+		err     string // expected LimitError
+	}{
+		{Limits{}, 10, ""},
+		{Limits{Entries: 10}, 10, ""},
+		{Limits{Entries: 4}, 4, "entries"},
+		{Limits{Bytes: 1000}, 10, ""},
+		{Limits{Bytes: 350}, 4, "bytes"},
+		{Limits{Ratio: 10}, 10, ""},
+		{Limits{Ratio: 2.5}, 3, "ratio"},
+	} {
+		d := Limit(&fakeD{n: 10, sz: 100}, tc.lim, 100)
+		var n int
+		var err error
+		for err = d.Next(); err == nil; err = d.Next() {
+			n++
+			if _, err = io.Copy(io.Discard, d.Reader()); err != nil {
+				break
+			}
+		}
+		if n != tc.entries {
+			t.Errorf("%v: expecting %d entries, got %d", tc.lim, tc.entries, n)
+		}
+		if tc.err == "" {
+			if err != io.EOF {
+				t.Errorf("%v: expecting EOF, got %v", tc.lim, err)
+			}
+			continue
+		}
+		le, ok := err.(LimitError)
+		if !ok || le.Limit != tc.err {
+			t.Errorf("%v: expecting %s limit error, got %v", tc.lim, tc.err, err)
+		}
+		// subsequent calls to Next should also fail
+		if d.Next() != err {
+			t.Errorf("%v: expecting limit error to persist", tc.lim)
+		}
+	}
+	if err := (Limits{Depth: 2}).Nested(1); err != nil {
+		t.Errorf("expecting no error at depth 1, got %v", err)
+	}
+	if err := (Limits{Depth: 2}).Nested(2); err == nil || !strings.Contains(err.Error(), "nesting depth") {
+		t.Errorf("expecting a depth error at depth 2, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decompress
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+// Outlook messages (MS-OXMSG) are decompressed to their attachments.
+// Each attachment is a storage in the root of the compound file with property streams for its data, name and MIME type.
+// Embedded messages and OLE objects (attachments whose data is a storage rather than a stream) are skipped.
+
+const (
+	msgAttachPrefix = "__attach_version1.0_#"
+	msgProp         = "__substg1.0_"
+	msgAttachData   = "37010102" // PidTagAttachDataBinary
+	msgLongName     = "3707"     // PidTagAttachLongFilename
+	msgShortName    = "3704"     // PidTagAttachFilename
+	msgMimeTag      = "370E"     // PidTagAttachMimeTag
+	msgUnicode      = "001F"
+	msgString8      = "001E"
+)
+
+type msgD struct {
+	p    string
+	atts []*msgAttachment
+	idx  int
+}
+
+type msgAttachment struct {
+	storage   string
+	data      *mscfb.File
+	name      string
+	shortName string
+	mime      string
+	mod       time.Time
+}
+
+func newMSG(b *siegreader.Buffer, path string) (Decompressor, error) {
+	b.Quit = make(chan struct{}) // in case a stream with a closed quit channel, make a new one
+	_ = b.SizeNow()              // in case a stream, force full read
+	rdr, err := mscfb.New(siegreader.ReaderFrom(b))
+	if err != nil {
+		return nil, err
+	}
+	atts := make(map[string]*msgAttachment)
+	get := func(storage string) *msgAttachment {
+		if atts[storage] == nil {
+			atts[storage] = &msgAttachment{storage: storage}
+		}
+		return atts[storage]
+	}
+	for entry, err := rdr.Next(); err == nil; entry, err = rdr.Next() {
+		switch {
+		case len(entry.Path) == 0 && strings.HasPrefix(entry.Name, msgAttachPrefix):
+			get(entry.Name).mod = entry.Modified()
+		case len(entry.Path) == 1 && strings.HasPrefix(entry.Path[0], msgAttachPrefix) && strings.HasPrefix(entry.Name, msgProp):
+			att := get(entry.Path[0])
+			prop := strings.ToUpper(strings.TrimPrefix(entry.Name, msgProp))
+			if prop == msgAttachData {
+				att.data = entry
+				continue
+			}
+			if len(prop) != 8 || (prop[4:] != msgUnicode && prop[4:] != msgString8) {
+				continue
+			}
+			switch prop[:4] {
+			case msgLongName:
+				att.name = msgString(entry, prop[4:] == msgUnicode)
+			case msgShortName:
+				att.shortName = msgString(entry, prop[4:] == msgUnicode)
+			case msgMimeTag:
+				att.mime = msgString(entry, prop[4:] == msgUnicode)
+			}
+		}
+	}
+	m := &msgD{p: path, idx: -1}
+	for _, att := range atts {
+		if att.data != nil {
+			m.atts = append(m.atts, att)
+		}
+	}
+	sort.Slice(m.atts, func(i, j int) bool { return m.atts[i].storage < m.atts[j].storage })
+	return m, nil
+}
+
+// msgString reads a string property stream: either UTF-16LE or 8-bit, and null terminated
+func msgString(f *mscfb.File, unicode bool) string {
+	byt, err := io.ReadAll(f)
+	if err != nil {
+		return ""
+	}
+	if !unicode {
+		return strings.TrimRight(string(byt), "\x00")
+	}
+	u := make([]uint16, len(byt)/2)
+	for i := range u {
+		u[i] = uint16(byt[i*2]) | uint16(byt[i*2+1])<<8
+	}
+	return strings.TrimRight(string(utf16.Decode(u)), "\x00")
+}
+
+func (m *msgD) Next() error {
+	m.idx++
+	if m.idx >= len(m.atts) {
+		return io.EOF
+	}
+	return nil
+}
+
+func (m *msgD) Reader() io.Reader {
+	return m.atts[m.idx].data
+}
+
+func (m *msgD) Path() string {
+	att := m.atts[m.idx]
+	name := att.name
+	if name == "" {
+		name = att.shortName
+	}
+	if name == "" {
+		name = fmt.Sprintf("attachment-%d", m.idx+1)
+	}
+	return Arcpath(m.p, safeName.Replace(name))
+}
+
+func (m *msgD) MIME() string {
+	return m.atts[m.idx].mime
+}
+
+func (m *msgD) Size() int64 {
+	return m.atts[m.idx].data.Size
+}
+
+func (m *msgD) Mod() time.Time {
+	return m.atts[m.idx].mod
+}
+
+func (m *msgD) Dirs() []string {
+	return nil
+}
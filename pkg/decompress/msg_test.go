@@ -0,0 +1,198 @@
+package decompress
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+type cfbEntry struct {
+	name     string
+	data     []byte // streams only
+	children []cfbEntry
+}
+
+var msgMod = time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+
+func appendLE32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func msgUTF16(s string) []byte {
+	var b []byte
+	for _, u := range utf16.Encode([]rune(s + "\x00")) {
+		b = append(b, byte(u), byte(u>>8))
+	}
+	return b
+}
+
+// makeCFB builds a version 3 compound file from a tree of storages (entries without data) and streams.
+// All streams are smaller than the cutoff so are stored in the mini stream.
+// Sectors are: the FAT, the directory, the mini FAT and then the mini stream.
+func makeCFB(entries []cfbEntry) []byte {
+	const noStream = 0xFFFFFFFF
+	var dir [][]byte
+	var mini, miniFAT []byte
+	entry := func(name string, typ byte, start, size uint32) []byte {
+		e := make([]byte, 128)
+		n := msgUTF16(name)
+		copy(e, n)
+		binary.LittleEndian.PutUint16(e[64:], uint16(len(n)))
+		e[66], e[67] = typ, 1
+		binary.LittleEndian.PutUint32(e[68:], noStream)
+		binary.LittleEndian.PutUint32(e[72:], noStream)
+		binary.LittleEndian.PutUint32(e[76:], noStream)
+		binary.LittleEndian.PutUint32(e[116:], start)
+		binary.LittleEndian.PutUint32(e[120:], size)
+		return e
+	}
+	dir = append(dir, entry("Root Entry", 5, 0, 0))
+	// link siblings as a chain of right siblings
+	link := func(parent int, ids []int) {
+		if len(ids) == 0 {
+			return
+		}
+		binary.LittleEndian.PutUint32(dir[parent][76:], uint32(ids[0]))
+		for i := 1; i < len(ids); i++ {
+			binary.LittleEndian.PutUint32(dir[ids[i-1]][72:], uint32(ids[i]))
+		}
+	}
+	var add func(parent int, entries []cfbEntry)
+	add = func(parent int, entries []cfbEntry) {
+		var ids []int
+		for _, e := range entries {
+			ids = append(ids, len(dir))
+			if e.data == nil {
+				st := entry(e.name, 1, 0, 0)
+				binary.LittleEndian.PutUint64(st[108:], uint64(msgMod.Unix()+11644473600)*1e7)
+				dir = append(dir, st)
+				add(len(dir)-1, e.children)
+				continue
+			}
+			dir = append(dir, entry(e.name, 2, uint32(len(mini)/64), uint32(len(e.data))))
+			n := (len(e.data) + 63) / 64
+			for i := 0; i < n; i++ {
+				next := uint32(len(mini)/64 + i + 1)
+				if i == n-1 {
+					next = 0xFFFFFFFE
+				}
+				miniFAT = appendLE32(miniFAT, next)
+			}
+			mini = append(mini, e.data...)
+			mini = append(mini, make([]byte, n*64-len(e.data))...)
+		}
+		link(parent, ids)
+	}
+	add(0, entries)
+	pad := func(b []byte) []byte {
+		if len(b)%512 == 0 && len(b) > 0 {
+			return b
+		}
+		return append(b, make([]byte, 512-len(b)%512)...)
+	}
+	dirBytes := pad(bytes.Join(dir, nil))
+	for len(miniFAT)%512 != 0 || len(miniFAT) == 0 {
+		miniFAT = appendLE32(miniFAT, 0xFFFFFFFF)
+	}
+	mini = pad(mini)
+	dirN, mfN, msN := len(dirBytes)/512, len(miniFAT)/512, len(mini)/512
+	binary.LittleEndian.PutUint32(dirBytes[116:], uint32(1+dirN+mfN))
+	binary.LittleEndian.PutUint32(dirBytes[120:], uint32(len(mini)))
+	fat := make([]byte, 0, 512)
+	fat = appendLE32(fat, 0xFFFFFFFD)
+	chain := func(start, n int) {
+		for i := 0; i < n; i++ {
+			next := uint32(start + i + 1)
+			if i == n-1 {
+				next = 0xFFFFFFFE
+			}
+			fat = appendLE32(fat, next)
+		}
+	}
+	chain(1, dirN)
+	chain(1+dirN, mfN)
+	chain(1+dirN+mfN, msN)
+	for len(fat) < 512 {
+		fat = appendLE32(fat, 0xFFFFFFFF)
+	}
+	hdr := make([]byte, 512)
+	copy(hdr, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1})
+	binary.LittleEndian.PutUint16(hdr[24:], 0x3E)
+	binary.LittleEndian.PutUint16(hdr[26:], 3)
+	binary.LittleEndian.PutUint16(hdr[28:], 0xFFFE)
+	binary.LittleEndian.PutUint16(hdr[30:], 9)
+	binary.LittleEndian.PutUint16(hdr[32:], 6)
+	binary.LittleEndian.PutUint32(hdr[44:], 1)
+	binary.LittleEndian.PutUint32(hdr[48:], 1)
+	binary.LittleEndian.PutUint32(hdr[56:], 4096)
+	binary.LittleEndian.PutUint32(hdr[60:], uint32(1+dirN))
+	binary.LittleEndian.PutUint32(hdr[64:], uint32(mfN))
+	binary.LittleEndian.PutUint32(hdr[68:], 0xFFFFFFFE)
+	for i := 80; i < 512; i += 4 {
+		binary.LittleEndian.PutUint32(hdr[i:], 0xFFFFFFFF)
+	}
+	return bytes.Join([][]byte{hdr, fat, dirBytes, miniFAT, mini}, nil)
+}
+
+func TestMSG(t *testing.T) {
+	cfb := makeCFB([]cfbEntry{
+		{name: "__substg1.0_0037001F", data: msgUTF16("subject")},
+		{name: "__attach_version1.0_#00000001", children: []cfbEntry{
+			{name: "__substg1.0_3704001F", data: msgUTF16("REPORT~1.PDF")},
+			{name: "__substg1.0_37010102", data: []byte("%PDF-1.4\n%%EOF\n")},
+		}},
+		{name: "__attach_version1.0_#00000000", children: []cfbEntry{
+			{name: "__substg1.0_3707001F", data: msgUTF16("hello world.txt")},
+			{name: "__substg1.0_370E001E", data: []byte("text/plain\x00")},
+			{name: "__substg1.0_37010102", data: []byte("hello world")},
+		}},
+		{name: "__attach_version1.0_#00000002", children: []cfbEntry{ // an embedded message, which is skipped
+			{name: "__substg1.0_3707001F", data: msgUTF16("forwarded.msg")},
+		}},
+	})
+	bufs := siegreader.New()
+	buf, err := bufs.Get(bytes.NewReader(cfb))
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	defer bufs.Put(buf)
+	d, err := New(config.MSG, buf, "test.msg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []struct {
+		name, mime, content string
+	}{
+		{"hello world.txt", "text/plain", "hello world"},
+		{"REPORT~1.PDF", "", "%PDF-1.4\n%%EOF\n"},
+	}
+	var i int
+	for err = d.Next(); err == nil; err = d.Next() {
+		if i >= len(expect) {
+			t.Fatalf("unexpected attachment %s", d.Path())
+		}
+		byt, _ := io.ReadAll(d.Reader())
+		if d.Path() != Arcpath("test.msg", expect[i].name) || d.MIME() != expect[i].mime || string(byt) != expect[i].content {
+			t.Errorf("expecting %v, got %s %s %s", expect[i], d.Path(), d.MIME(), byt)
+		}
+		if d.Size() != int64(len(byt)) {
+			t.Errorf("expecting size %d, got %d", len(byt), d.Size())
+		}
+		if !d.Mod().Equal(msgMod) {
+			t.Errorf("expecting mod time %v, got %v", msgMod, d.Mod())
+		}
+		i++
+	}
+	if err != io.EOF {
+		t.Fatal(err)
+	}
+	if i != len(expect) {
+		t.Errorf("expecting %d attachments, got %d", len(expect), i)
+	}
+}
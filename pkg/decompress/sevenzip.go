@@ -0,0 +1,746 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decompress
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/ulikunitz/xz/lzma"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+// 7z format described in DOC/7zFormat.txt of the 7-Zip sources
+var sevenZipMagic = []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}
+
+const sevenZipHeaderLen = 32
+
+// property IDs
+const (
+	szEnd = iota
+	szHeader
+	szArchiveProperties
+	szAdditionalStreamsInfo
+	szMainStreamsInfo
+	szFilesInfo
+	szPackInfo
+	szUnpackInfo
+	szSubStreamsInfo
+	szSize
+	szCRC
+	szFolder
+	szCodersUnpackSize
+	szNumUnpackStream
+	szEmptyStream
+	szEmptyFile
+	szAnti
+	szName
+	szCTime
+	szATime
+	szMTime
+	szWinAttributes
+	szComment
+	szEncodedHeader
+)
+
+// coder IDs
+const (
+	szCopy  = "\x00"
+	szLZMA  = "\x03\x01\x01"
+	szLZMA2 = "\x21"
+	szAES   = "\x06\xf1\x07\x01"
+)
+
+var errSevenZip = errors.New("7z: corrupt header")
+
+// 7-Zip itself won't read folders with more coders, or coder streams, than this
+const sevenZipMaxStreams = 64
+
+type sevenZipD struct {
+	p       string
+	ra      io.ReaderAt
+	folders []*sevenZipFolder
+	files   []sevenZipFile
+	idx     int
+	fidx    int       // index of the folder currently being decoded
+	fr      io.Reader // reader for the current folder
+	ferr    error     // EntryError if the current folder can't be unpacked
+	rdr     io.Reader // reader for the current file
+	written map[string]bool
+}
+
+type sevenZipFile struct {
+	name   string
+	dir    bool
+	folder int // -1 for files without a stream (i.e. empty files)
+	size   int64
+	mod    time.Time
+}
+
+type sevenZipCoder struct {
+	id    string
+	in    int
+	out   int
+	props []byte
+}
+
+type sevenZipFolder struct {
+	coders      []sevenZipCoder
+	bound       map[int]bool // output streams bound to coder inputs
+	packed      int          // number of packed streams
+	unpackSizes []int64
+	crc         bool // folder has a CRC
+	streams     int  // number of files stored in the folder
+	packOff     int64
+	packSize    int64
+}
+
+// the unpack size of a folder is the size of its unbound output stream
+func (f *sevenZipFolder) unpackSize() int64 {
+	for i, sz := range f.unpackSizes {
+		if !f.bound[i] {
+			return sz
+		}
+	}
+	return 0
+}
+
+func newSevenZip(b *siegreader.Buffer, path string) (Decompressor, error) {
+	b.Quit = make(chan struct{}) // in case a stream with a closed quit channel, make a new one
+	sz := b.SizeNow()            // in case a stream, force full read
+	s := &sevenZipD{p: path, ra: siegreader.ReaderFrom(b), idx: -1, fidx: -1}
+	hdr := make([]byte, sevenZipHeaderLen)
+	if _, err := s.ra.ReadAt(hdr, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.Equal(hdr[:6], sevenZipMagic) {
+		return nil, errors.New("7z: bad signature")
+	}
+	off, l := binary.LittleEndian.Uint64(hdr[12:]), binary.LittleEndian.Uint64(hdr[20:])
+	if l == 0 {
+		return s, nil // empty archive
+	}
+	if off > uint64(sz) || l > uint64(sz) || sevenZipHeaderLen+off+l > uint64(sz) {
+		return nil, errSevenZip
+	}
+	buf := make([]byte, l)
+	if _, err := io.ReadFull(io.NewSectionReader(s.ra, int64(sevenZipHeaderLen+off), int64(l)), buf); err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(buf) != binary.LittleEndian.Uint32(hdr[28:]) {
+		return nil, errors.New("7z: header checksum mismatch")
+	}
+	r := &szReader{b: buf}
+	id := r.byte()
+	if id == szEncodedHeader {
+		folders, _, err := s.streamsInfo(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(folders) == 0 {
+			return nil, errSevenZip
+		}
+		fr, err := s.folderReader(folders[0])
+		if err != nil {
+			return nil, err
+		}
+		buf, err = io.ReadAll(io.LimitReader(fr, folders[0].unpackSize()))
+		if err != nil {
+			return nil, err
+		}
+		r = &szReader{b: buf}
+		id = r.byte()
+	}
+	if id != szHeader {
+		return nil, errSevenZip
+	}
+	return s, s.header(r)
+}
+
+func (s *sevenZipD) header(r *szReader) error {
+	var sizes []int64
+	id := r.byte()
+	if id == szArchiveProperties {
+		for t := r.byte(); t != szEnd && r.err == nil; t = r.byte() {
+			r.bytes(r.number())
+		}
+		id = r.byte()
+	}
+	if id == szAdditionalStreamsInfo {
+		if _, _, err := s.streamsInfo(r); err != nil {
+			return err
+		}
+		id = r.byte()
+	}
+	if id == szMainStreamsInfo {
+		var err error
+		s.folders, sizes, err = s.streamsInfo(r)
+		if err != nil {
+			return err
+		}
+		id = r.byte()
+	}
+	if id == szFilesInfo {
+		if err := s.filesInfo(r, sizes); err != nil {
+			return err
+		}
+		id = r.byte()
+	}
+	if id != szEnd || r.err != nil {
+		return errSevenZip
+	}
+	return nil
+}
+
+// returns folders and the sizes of all the unpacked streams within those folders
+func (s *sevenZipD) streamsInfo(r *szReader) ([]*sevenZipFolder, []int64, error) {
+	var (
+		packPos   uint64
+		packSizes []int64
+		folders   []*sevenZipFolder
+		sizes     []int64
+		subs      bool
+	)
+	for id := r.byte(); id != szEnd; id = r.byte() {
+		if r.err != nil {
+			return nil, nil, errSevenZip
+		}
+		switch id {
+		case szPackInfo:
+			packPos = r.number()
+			packSizes = make([]int64, r.count())
+			for id = r.byte(); id != szEnd && r.err == nil; id = r.byte() {
+				switch id {
+				case szSize:
+					for i := range packSizes {
+						packSizes[i] = int64(r.number())
+					}
+				case szCRC:
+					r.digests(len(packSizes))
+				default:
+					return nil, nil, errSevenZip
+				}
+			}
+		case szUnpackInfo:
+			var err error
+			if folders, err = unpackInfo(r); err != nil {
+				return nil, nil, err
+			}
+		case szSubStreamsInfo:
+			var err error
+			if sizes, err = subStreamsInfo(r, folders); err != nil {
+				return nil, nil, err
+			}
+			subs = true
+		default:
+			return nil, nil, errSevenZip
+		}
+	}
+	// without substreams info, each folder holds a single stream
+	if !subs {
+		for _, f := range folders {
+			f.streams = 1
+			sizes = append(sizes, f.unpackSize())
+		}
+	}
+	// locate the packed streams for each folder
+	var pidx int
+	off := int64(sevenZipHeaderLen + packPos)
+	for _, f := range folders {
+		if pidx+f.packed > len(packSizes) {
+			return nil, nil, errSevenZip
+		}
+		f.packOff = off
+		for _, sz := range packSizes[pidx : pidx+f.packed] {
+			f.packSize += sz
+		}
+		off += f.packSize
+		pidx += f.packed
+	}
+	return folders, sizes, r.err
+}
+
+func unpackInfo(r *szReader) ([]*sevenZipFolder, error) {
+	if r.byte() != szFolder {
+		return nil, errSevenZip
+	}
+	folders := make([]*sevenZipFolder, r.count())
+	if r.byte() != 0 {
+		return nil, errors.New("7z: external folders not supported")
+	}
+	for i := range folders {
+		f, err := folder(r)
+		if err != nil {
+			return nil, err
+		}
+		folders[i] = f
+	}
+	if r.byte() != szCodersUnpackSize {
+		return nil, errSevenZip
+	}
+	for _, f := range folders {
+		for i := range f.unpackSizes {
+			f.unpackSizes[i] = int64(r.number())
+		}
+	}
+	for id := r.byte(); id != szEnd && r.err == nil; id = r.byte() {
+		if id != szCRC {
+			return nil, errSevenZip
+		}
+		for i, d := range r.digests(len(folders)) {
+			folders[i].crc = d
+		}
+	}
+	return folders, r.err
+}
+
+func folder(r *szReader) (*sevenZipFolder, error) {
+	n := r.count()
+	if n > sevenZipMaxStreams {
+		return nil, errSevenZip
+	}
+	f := &sevenZipFolder{coders: make([]sevenZipCoder, n), bound: make(map[int]bool)}
+	var ins, outs int
+	for i := range f.coders {
+		flags := r.byte()
+		if flags&0x80 != 0 {
+			return nil, errors.New("7z: alternative coder methods not supported")
+		}
+		c := sevenZipCoder{id: string(r.bytes(uint64(flags & 0x0F))), in: 1, out: 1}
+		if flags&0x10 != 0 {
+			c.in, c.out = int(r.count()), int(r.count())
+		}
+		if flags&0x20 != 0 {
+			c.props = r.bytes(r.number())
+		}
+		ins += c.in
+		outs += c.out
+		if ins > sevenZipMaxStreams || outs > sevenZipMaxStreams {
+			return nil, errSevenZip
+		}
+		f.coders[i] = c
+	}
+	if outs < 1 || r.err != nil {
+		return nil, errSevenZip
+	}
+	for i := 0; i < outs-1; i++ {
+		r.number() // InIndex
+		f.bound[int(r.number())] = true
+	}
+	f.packed = ins - (outs - 1)
+	if f.packed < 1 {
+		return nil, errSevenZip
+	}
+	if f.packed > 1 {
+		for i := 0; i < f.packed; i++ {
+			r.number()
+		}
+	}
+	f.unpackSizes = make([]int64, outs)
+	return f, r.err
+}
+
+func subStreamsInfo(r *szReader, folders []*sevenZipFolder) ([]int64, error) {
+	for _, f := range folders {
+		f.streams = 1
+	}
+	var sizes []int64
+	id := r.byte()
+	if id == szNumUnpackStream {
+		for _, f := range folders {
+			f.streams = int(r.count())
+		}
+		id = r.byte()
+	}
+	for _, f := range folders {
+		if f.streams == 0 {
+			continue
+		}
+		var sum int64
+		if id == szSize {
+			for i := 1; i < f.streams; i++ {
+				sz := int64(r.number())
+				sizes = append(sizes, sz)
+				sum += sz
+			}
+		} else if f.streams > 1 {
+			return nil, errSevenZip
+		}
+		sizes = append(sizes, f.unpackSize()-sum)
+	}
+	if id == szSize {
+		id = r.byte()
+	}
+	for ; id != szEnd && r.err == nil; id = r.byte() {
+		if id != szCRC {
+			r.bytes(r.number())
+			continue
+		}
+		var n int
+		for _, f := range folders {
+			if f.streams != 1 || !f.crc {
+				n += f.streams
+			}
+		}
+		r.digests(n)
+	}
+	return sizes, r.err
+}
+
+func (s *sevenZipD) filesInfo(r *szReader, sizes []int64) error {
+	s.files = make([]sevenZipFile, r.count())
+	var (
+		emptyStreams []bool
+		emptyFiles   []bool
+		numEmpty     int
+	)
+	for t := r.number(); t != szEnd; t = r.number() {
+		if r.err != nil {
+			return errSevenZip
+		}
+		pr := &szReader{b: r.bytes(r.number())}
+		switch t {
+		case szEmptyStream:
+			emptyStreams = pr.bits(len(s.files))
+			for _, e := range emptyStreams {
+				if e {
+					numEmpty++
+				}
+			}
+		case szEmptyFile:
+			emptyFiles = pr.bits(numEmpty)
+		case szName:
+			if pr.byte() != 0 {
+				return errors.New("7z: external file names not supported")
+			}
+			names := szNames(pr.b[pr.i:])
+			if len(names) < len(s.files) {
+				return errSevenZip
+			}
+			for i := range s.files {
+				s.files[i].name = names[i]
+			}
+		case szMTime:
+			defined := pr.defined(len(s.files))
+			if pr.byte() != 0 {
+				return errors.New("7z: external file times not supported")
+			}
+			for i, d := range defined {
+				if d {
+					s.files[i].mod = filetime(pr.uint64())
+				}
+			}
+		case szWinAttributes:
+			defined := pr.defined(len(s.files))
+			if pr.byte() != 0 {
+				return errors.New("7z: external file attributes not supported")
+			}
+			for i, d := range defined {
+				if d && pr.uint32()&0x10 == 0x10 { // FILE_ATTRIBUTE_DIRECTORY
+					s.files[i].dir = true
+				}
+			}
+		}
+		if pr.err != nil {
+			return errSevenZip
+		}
+	}
+	// assign streams to files
+	var fidx, sidx, eidx, stream int
+	for i := range s.files {
+		if emptyStreams != nil && emptyStreams[i] {
+			s.files[i].folder = -1
+			if emptyFiles != nil && eidx >= len(emptyFiles) {
+				return errSevenZip
+			}
+			if emptyFiles == nil || !emptyFiles[eidx] {
+				s.files[i].dir = true
+			}
+			eidx++
+			continue
+		}
+		for sidx == 0 && fidx < len(s.folders) && s.folders[fidx].streams == 0 {
+			fidx++
+		}
+		if fidx >= len(s.folders) || stream >= len(sizes) {
+			return errSevenZip
+		}
+		s.files[i].folder, s.files[i].size = fidx, sizes[stream]
+		stream++
+		sidx++
+		if sidx >= s.folders[fidx].streams {
+			fidx++
+			sidx = 0
+		}
+	}
+	return nil
+}
+
+// returns an io.Reader for the unpacked contents of a folder.
+// Folders that are encrypted or use unsupported methods return an EntryError.
+func (s *sevenZipD) folderReader(f *sevenZipFolder) (io.Reader, error) {
+	for _, c := range f.coders {
+		if c.id == szAES {
+			return nil, ErrEncrypted
+		}
+	}
+	if len(f.coders) != 1 || f.packed != 1 {
+		for _, c := range f.coders {
+			if c.id != szCopy && c.id != szLZMA && c.id != szLZMA2 {
+				return nil, sevenZipMethod(c.id)
+			}
+		}
+		return nil, EntryError("unsupported coder chain")
+	}
+	c := f.coders[0]
+	pr := io.NewSectionReader(s.ra, f.packOff, f.packSize)
+	switch c.id {
+	case szCopy:
+		return pr, nil
+	case szLZMA:
+		if len(c.props) != 5 {
+			return nil, errSevenZip
+		}
+		// construct a standalone LZMA header: properties, dictionary size, uncompressed size
+		hdr := make([]byte, lzma.HeaderLen)
+		copy(hdr, c.props)
+		binary.LittleEndian.PutUint32(hdr[1:], uint32(dictCap(int64(binary.LittleEndian.Uint32(c.props[1:])), f.unpackSize())))
+		binary.LittleEndian.PutUint64(hdr[5:], uint64(f.unpackSize()))
+		return lzma.NewReader(io.MultiReader(bytes.NewReader(hdr), pr))
+	case szLZMA2:
+		if len(c.props) != 1 {
+			return nil, errSevenZip
+		}
+		dc, err := lzma.DecodeDictCap(c.props[0])
+		if err != nil {
+			return nil, err
+		}
+		return lzma.Reader2Config{DictCap: dictCap(dc, f.unpackSize())}.NewReader2(pr)
+	}
+	return nil, sevenZipMethod(c.id)
+}
+
+// 7z method IDs are big-endian byte strings
+func sevenZipMethod(id string) EntryError {
+	var m uint64
+	for i := 0; i < len(id); i++ {
+		m = m<<8 | uint64(id[i])
+	}
+	return unsupportedMethod(m)
+}
+
+// the dictionary need never be larger than the data it decodes
+func dictCap(dc, sz int64) int {
+	if sz < dc {
+		dc = sz
+	}
+	if dc < lzma.MinDictCap {
+		return lzma.MinDictCap
+	}
+	return int(dc)
+}
+
+func (s *sevenZipD) Next() error {
+	if s.rdr != nil {
+		// files within a folder are stored contiguously, so drain any unread content
+		if _, err := io.Copy(io.Discard, s.rdr); err != nil {
+			return err
+		}
+		s.rdr = nil
+	}
+	s.idx++
+	// scan past directories
+	for ; s.idx < len(s.files) && s.files[s.idx].dir; s.idx++ {
+	}
+	if s.idx >= len(s.files) {
+		return io.EOF
+	}
+	f := s.files[s.idx]
+	if f.folder < 0 {
+		s.rdr = bytes.NewReader(nil)
+		return nil
+	}
+	if f.folder != s.fidx {
+		s.fidx = f.folder
+		s.fr, s.ferr = s.folderReader(s.folders[f.folder])
+		if _, ok := s.ferr.(EntryError); s.ferr != nil && !ok {
+			return s.ferr
+		}
+	}
+	if s.ferr != nil {
+		return s.ferr // report each file in the folder, then carry on with the next folder
+	}
+	s.rdr = io.LimitReader(s.fr, f.size)
+	return nil
+}
+
+func (s *sevenZipD) Reader() io.Reader {
+	return s.rdr
+}
+
+func (s *sevenZipD) Path() string {
+	return Arcpath(s.p, filepath.FromSlash(s.files[s.idx].name))
+}
+
+func (s *sevenZipD) MIME() string {
+	return ""
+}
+
+func (s *sevenZipD) Size() int64 {
+	return s.files[s.idx].size
+}
+
+func (s *sevenZipD) Mod() time.Time {
+	return s.files[s.idx].mod
+}
+
+func (s *sevenZipD) Dirs() []string {
+	if s.written == nil {
+		s.written = make(map[string]bool)
+	}
+	return dirs(s.p, s.files[s.idx].name, s.written)
+}
+
+// szReader reads the property encoded header. Errors are sticky and should be checked after reading.
+type szReader struct {
+	b   []byte
+	i   int
+	err error
+}
+
+func (r *szReader) byte() byte {
+	if r.i >= len(r.b) {
+		r.err = errSevenZip
+		return 0
+	}
+	r.i++
+	return r.b[r.i-1]
+}
+
+func (r *szReader) bytes(n uint64) []byte {
+	if n > uint64(len(r.b)-r.i) {
+		r.err = errSevenZip
+		r.i = len(r.b)
+		return nil
+	}
+	r.i += int(n)
+	return r.b[r.i-int(n) : r.i]
+}
+
+// number reads 7z's variable length integer: the count of leading 1 bits in the first byte gives the number of bytes that follow
+func (r *szReader) number() uint64 {
+	first := r.byte()
+	var val uint64
+	mask := byte(0x80)
+	for i := 0; i < 8; i++ {
+		if first&mask == 0 {
+			return val | uint64(first&(mask-1))<<(8*i)
+		}
+		val |= uint64(r.byte()) << (8 * i)
+		mask >>= 1
+	}
+	return val
+}
+
+// count reads a number that is used to size a slice; it can't be larger than the remaining header
+func (r *szReader) count() int {
+	n := r.number()
+	if n > uint64(len(r.b)-r.i) {
+		r.err = errSevenZip
+		return 0
+	}
+	return int(n)
+}
+
+func (r *szReader) uint32() uint32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (r *szReader) uint64() uint64 {
+	b := r.bytes(8)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+func (r *szReader) bits(n int) []bool {
+	ret := make([]bool, n)
+	var b, mask byte
+	for i := range ret {
+		if mask == 0 {
+			b, mask = r.byte(), 0x80
+		}
+		ret[i] = b&mask != 0
+		mask >>= 1
+	}
+	return ret
+}
+
+// defined reads an "all defined" byte, followed by a bit vector if not all defined
+func (r *szReader) defined(n int) []bool {
+	if r.byte() == 0 {
+		return r.bits(n)
+	}
+	ret := make([]bool, n)
+	for i := range ret {
+		ret[i] = true
+	}
+	return ret
+}
+
+func (r *szReader) digests(n int) []bool {
+	defined := r.defined(n)
+	for _, d := range defined {
+		if d {
+			r.uint32()
+		}
+	}
+	return defined
+}
+
+// names are null terminated UTF-16LE strings
+func szNames(b []byte) []string {
+	var ret []string
+	u := make([]uint16, 0, 64)
+	for i := 0; i+1 < len(b); i += 2 {
+		c := binary.LittleEndian.Uint16(b[i:])
+		if c == 0 {
+			ret = append(ret, strings.ReplaceAll(string(utf16.Decode(u)), "\\", "/"))
+			u = u[:0]
+			continue
+		}
+		u = append(u, c)
+	}
+	return ret
+}
+
+// filetimes are 100 nanosecond intervals since 1 January 1601
+func filetime(ft uint64) time.Time {
+	const epochDiff = 11644473600 // seconds between 1601 and 1970
+	return time.Unix(int64(ft/1e7)-epochDiff, int64(ft%1e7)*100)
+}
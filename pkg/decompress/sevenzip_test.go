@@ -0,0 +1,341 @@
+package decompress
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/ulikunitz/xz/lzma"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+type szEntry struct {
+	name    string
+	content string // empty content and dir means a directory
+	dir     bool
+}
+
+var szEntries = []szEntry{
+	{name: "docs", dir: true},
+	{name: "docs/hello.txt", content: "hello world"},
+	{name: "docs/empty.txt"},
+	{name: "readme.md", content: "# readme\n\nrepeat repeat repeat repeat repeat"},
+}
+
+var szMod = time.Date(2020, 2, 3, 4, 5, 6, 0, time.UTC)
+
+func szNumber(v uint64) []byte {
+	for i := 0; i < 8; i++ {
+		if v>>(8*i) < 1<<(7-i) {
+			b := []byte{byte(0xFF<<(8-i)) | byte(v>>(8*i))}
+			for j := 0; j < i; j++ {
+				b = append(b, byte(v>>(8*j)))
+			}
+			return b
+		}
+	}
+	b := make([]byte, 9)
+	b[0] = 0xFF
+	binary.LittleEndian.PutUint64(b[1:], v)
+	return b
+}
+
+func szBits(bits []bool) []byte {
+	b := make([]byte, (len(bits)+7)/8)
+	for i, v := range bits {
+		if v {
+			b[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return b
+}
+
+func szProp(buf *bytes.Buffer, id byte, data []byte) {
+	buf.WriteByte(id)
+	buf.Write(szNumber(uint64(len(data))))
+	buf.Write(data)
+}
+
+// make7z builds a single folder 7z archive using the given coder
+func make7z(t *testing.T, method string) []byte {
+	var unpacked bytes.Buffer
+	var sizes []uint64
+	var empty []bool
+	var emptyFiles []bool
+	for _, e := range szEntries {
+		if e.content == "" {
+			empty = append(empty, true)
+			emptyFiles = append(emptyFiles, !e.dir)
+			continue
+		}
+		empty = append(empty, false)
+		unpacked.WriteString(e.content)
+		sizes = append(sizes, uint64(len(e.content)))
+	}
+	var packed bytes.Buffer
+	var props []byte
+	switch method {
+	case szCopy:
+		packed.Write(unpacked.Bytes())
+	case szLZMA:
+		w, err := lzma.WriterConfig{DictCap: lzma.MinDictCap, SizeInHeader: true, Size: int64(unpacked.Len())}.NewWriter(&packed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(unpacked.Bytes())
+		w.Close()
+		props = append(props, packed.Bytes()[:5]...)
+		packed.Next(lzma.HeaderLen)
+	case szLZMA2:
+		w, err := lzma.Writer2Config{DictCap: lzma.MinDictCap}.NewWriter2(&packed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(unpacked.Bytes())
+		w.Close()
+		props = []byte{lzma.EncodeDictCap(lzma.MinDictCap)}
+	}
+	var hdr bytes.Buffer
+	hdr.Write([]byte{szHeader, szMainStreamsInfo, szPackInfo, 0})
+	hdr.Write(szNumber(1))
+	hdr.WriteByte(szSize)
+	hdr.Write(szNumber(uint64(packed.Len())))
+	hdr.Write([]byte{szEnd, szUnpackInfo, szFolder, 1, 0, 1})
+	flags := byte(len(method))
+	if props != nil {
+		flags |= 0x20
+	}
+	hdr.WriteByte(flags)
+	hdr.WriteString(method)
+	if props != nil {
+		hdr.Write(szNumber(uint64(len(props))))
+		hdr.Write(props)
+	}
+	hdr.WriteByte(szCodersUnpackSize)
+	hdr.Write(szNumber(uint64(unpacked.Len())))
+	hdr.Write([]byte{szEnd, szSubStreamsInfo, szNumUnpackStream})
+	hdr.Write(szNumber(uint64(len(sizes))))
+	hdr.WriteByte(szSize)
+	for _, sz := range sizes[:len(sizes)-1] {
+		hdr.Write(szNumber(sz))
+	}
+	hdr.Write([]byte{szEnd, szEnd, szFilesInfo})
+	hdr.Write(szNumber(uint64(len(szEntries))))
+	szProp(&hdr, szEmptyStream, szBits(empty))
+	szProp(&hdr, szEmptyFile, szBits(emptyFiles))
+	names := []byte{0}
+	for _, e := range szEntries {
+		for _, u := range utf16.Encode([]rune(e.name + "\x00")) {
+			names = append(names, byte(u), byte(u>>8))
+		}
+	}
+	szProp(&hdr, szName, names)
+	times := []byte{1, 0}
+	for range szEntries {
+		ft := make([]byte, 8)
+		binary.LittleEndian.PutUint64(ft, uint64(szMod.Unix()+11644473600)*1e7)
+		times = append(times, ft...)
+	}
+	szProp(&hdr, szMTime, times)
+	hdr.Write([]byte{szEnd, szEnd})
+	start := make([]byte, sevenZipHeaderLen)
+	copy(start, sevenZipMagic)
+	start[7] = 4
+	binary.LittleEndian.PutUint64(start[12:], uint64(packed.Len()))
+	binary.LittleEndian.PutUint64(start[20:], uint64(hdr.Len()))
+	binary.LittleEndian.PutUint32(start[28:], crc32.ChecksumIEEE(hdr.Bytes()))
+	binary.LittleEndian.PutUint32(start[8:], crc32.ChecksumIEEE(start[12:]))
+	return append(append(start, packed.Bytes()...), hdr.Bytes()...)
+}
+
+func TestSevenZip(t *testing.T) {
+	bufs := siegreader.New()
+	for _, method := range []string{szCopy, szLZMA, szLZMA2} {
+		buf, err := bufs.Get(bytes.NewReader(make7z(t, method)))
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		d, err := New(config.SevenZip, buf, "test.7z")
+		if err != nil {
+			t.Fatalf("method %x: %v", method, err)
+		}
+		var got []szEntry
+		for err = d.Next(); err == nil; err = d.Next() {
+			byt, err := io.ReadAll(d.Reader())
+			if err != nil {
+				t.Fatalf("method %x: %v", method, err)
+			}
+			if d.Size() != int64(len(byt)) {
+				t.Errorf("method %x: expecting size %d for %s, got %d", method, len(byt), d.Path(), d.Size())
+			}
+			if !d.Mod().Equal(szMod) {
+				t.Errorf("method %x: expecting mod time %v, got %v", method, szMod, d.Mod())
+			}
+			got = append(got, szEntry{name: d.Path(), content: string(byt)})
+		}
+		if err != io.EOF {
+			t.Fatalf("method %x: %v", method, err)
+		}
+		expect := []szEntry{
+			{name: Arcpath("test.7z", filepath.FromSlash("docs/hello.txt")), content: "hello world"},
+			{name: Arcpath("test.7z", filepath.FromSlash("docs/empty.txt"))},
+			{name: Arcpath("test.7z", "readme.md"), content: szEntries[3].content},
+		}
+		if len(got) != len(expect) {
+			t.Fatalf("method %x: expecting %v, got %v", method, expect, got)
+		}
+		for i := range expect {
+			if got[i] != expect[i] {
+				t.Errorf("method %x: expecting %v, got %v", method, expect[i], got[i])
+			}
+		}
+		bufs.Put(buf)
+	}
+}
+
+func TestSevenZipEntryErrors(t *testing.T) {
+	bufs := siegreader.New()
+	for method, expect := range map[string]error{
+		szAES:              ErrEncrypted,
+		"\x03\x03\x01\x03": unsupportedMethod(0x03030103), // BCJ
+	} {
+		buf, err := bufs.Get(bytes.NewReader(make7z(t, method)))
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		d, err := New(config.SevenZip, buf, "test.7z")
+		if err != nil {
+			t.Fatalf("method %x: %v", method, err)
+		}
+		var got []string
+		for err = d.Next(); err != io.EOF; err = d.Next() {
+			if err != nil {
+				if err != expect {
+					t.Fatalf("method %x: expecting %v, got %v", method, expect, err)
+				}
+				got = append(got, d.Path()+" "+err.Error())
+				continue
+			}
+			got = append(got, d.Path())
+		}
+		want := []string{
+			Arcpath("test.7z", filepath.FromSlash("docs/hello.txt")) + " " + expect.Error(),
+			Arcpath("test.7z", filepath.FromSlash("docs/empty.txt")),
+			Arcpath("test.7z", "readme.md") + " " + expect.Error(),
+		}
+		if len(got) != len(want) {
+			t.Fatalf("method %x: expecting %v, got %v", method, want, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("method %x: expecting %s, got %s", method, want[i], got[i])
+			}
+		}
+		bufs.Put(buf)
+	}
+}
+
+// archives written by other tools, rather than by make7z:
+// 7zip-lzma2.7z was written by 7-Zip (it is one of libarchive's test archives) and has an LZMA encoded header,
+// a directory and two files compressed with LZMA2. The bsdtar archives were written by libarchive
+// (bsdtar --format 7zip --options 7zip:compression=store|lzma1|lzma2 -cf test.7z docs) and have a directory,
+// a file and an empty file.
+func TestSevenZipFixtures(t *testing.T) {
+	bsdtar := []szEntry{
+		{name: filepath.FromSlash("docs/hello.txt"), content: "hello world"},
+		{name: filepath.FromSlash("docs/empty.txt")},
+	}
+	for name, expect := range map[string][]szEntry{
+		"7zip-lzma2.7z": {
+			{name: filepath.FromSlash("7zip-archive/hello"), content: "hello\n"},
+			{name: filepath.FromSlash("7zip-archive/world"), content: "world\n"},
+		},
+		"bsdtar-copy.7z":  bsdtar,
+		"bsdtar-lzma.7z":  bsdtar,
+		"bsdtar-lzma2.7z": bsdtar,
+	} {
+		byt, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, err := siegreader.New().Get(bytes.NewReader(byt))
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		d, err := New(config.SevenZip, buf, name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var got []szEntry
+		for err = d.Next(); err == nil; err = d.Next() {
+			byt, err := io.ReadAll(d.Reader())
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if d.Mod().IsZero() {
+				t.Errorf("%s: expecting a mod time for %s", name, d.Path())
+			}
+			got = append(got, szEntry{name: d.Path(), content: string(byt)})
+		}
+		if err != io.EOF {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(got) != len(expect) {
+			t.Fatalf("%s: expecting %v, got %v", name, expect, got)
+		}
+		for i, e := range expect {
+			e.name = Arcpath(name, e.name)
+			if got[i] != e {
+				t.Errorf("%s: expecting %v, got %v", name, e, got[i])
+			}
+		}
+	}
+}
+
+// szHeaderOf returns the property encoded header of a 7z archive, without its leading szHeader byte
+func szHeaderOf(b []byte) []byte {
+	off, l := binary.LittleEndian.Uint64(b[12:]), binary.LittleEndian.Uint64(b[20:])
+	return b[sevenZipHeaderLen+off+1 : sevenZipHeaderLen+off+l]
+}
+
+// corrupt and truncated headers should return errors rather than panic
+func TestSevenZipCorrupt(t *testing.T) {
+	padding := make([]byte, 256)
+	for name, hdr := range map[string][]byte{
+		"empty file before empty stream": {szFilesInfo, 2, szEmptyFile, 1, 0x80, szEmptyStream, 1, 0xC0, szEnd, szEnd},
+		"more empty streams than empty file bits": {szFilesInfo, 3, szEmptyStream, 1, 0x80, szEmptyFile, 1, 0x80,
+			szEmptyStream, 1, 0xE0, szEnd, szEnd},
+		"too many coders":        append([]byte{szMainStreamsInfo, szUnpackInfo, szFolder, 1, 0, 100}, padding...),
+		"too many coder streams": append([]byte{szMainStreamsInfo, szUnpackInfo, szFolder, 1, 0, 2, 0x11, 0, 40, 1, 0x11, 0, 40, 1}, padding...),
+	} {
+		s := &sevenZipD{ra: bytes.NewReader(nil)}
+		if err := s.header(&szReader{b: hdr}); err != errSevenZip {
+			t.Errorf("%s: expecting %v, got %v", name, errSevenZip, err)
+		}
+	}
+	hdrs := [][]byte{make7z(t, szCopy), make7z(t, szLZMA), make7z(t, szLZMA2)}
+	for _, name := range []string{"bsdtar-copy.7z", "bsdtar-lzma.7z", "bsdtar-lzma2.7z"} {
+		byt, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hdrs = append(hdrs, byt)
+	}
+	for _, byt := range hdrs {
+		hdr := szHeaderOf(byt)
+		for i := 0; i < len(hdr); i++ {
+			s := &sevenZipD{ra: bytes.NewReader(nil)}
+			if err := s.header(&szReader{b: hdr[:i]}); err == nil {
+				t.Errorf("expecting an error for a header truncated to %d bytes", i)
+			}
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decompress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/richardlehane/webarchive"
+)
+
+// WebFields are the names of the extra fields reported for the records in WARC and ARC files (see Fielder).
+// The digest-check field is added by CheckDigest.
+var WebFields = []string{"warc-type", "warc-id", "http-status", "http-headers", "encoding", "payload-digest", "truncated", "digest-check"}
+
+// Fielder is implemented by Decompressors that report extra fields (name/value pairs) for their entries, e.g. WARC record headers.
+type Fielder interface {
+	Fields() [][2]string
+}
+
+// Fields returns the record's fields that have values, in the order of WebFields.
+// Revisit records aren't reported as they don't have payloads (webarchive's NextPayload skips them).
+func (w *wa) Fields() [][2]string {
+	var typ, id, truncated string
+	if wr, ok := w.rec.(webarchive.WARCRecord); ok {
+		typ, id = wr.Type(), wr.ID()
+	}
+	if t := w.rec.Fields()["WARC-Truncated"]; len(t) > 0 {
+		truncated = t[0]
+	}
+	var enc []string
+	if w.pl != w.rec {
+		enc = w.hdr.encodings()
+	}
+	vals := []string{typ, id, w.hdr.status, strings.Join(w.hdr.http, "\n"), strings.Join(enc, ", "), w.hdr.digest, truncated}
+	var ret [][2]string
+	for i, v := range vals {
+		if v != "" {
+			ret = append(ret, [2]string{WebFields[i], v})
+		}
+	}
+	return ret
+}
+
+// recordHeader holds the fields of a web archive record that webarchive doesn't report: the Fields method of a
+// webarchive.Record drops values that contain a colon (e.g. WARC-Payload-Digest and many HTTP headers), and the HTTP
+// status line of a response is stripped along with its headers.
+type recordHeader struct {
+	digest string   // WARC-Payload-Digest
+	status string   // HTTP status, e.g. "200 OK"
+	http   []string // HTTP header lines
+}
+
+// encodings returns the encodings decoded by webarchive.DecodePayload, in the order they are decoded
+func (hdr recordHeader) encodings() []string {
+	var te, ce []string
+	for _, l := range hdr.http {
+		k, v, _ := strings.Cut(l, ":")
+		k = strings.TrimSpace(k)
+		switch {
+		case strings.EqualFold(k, "Transfer-Encoding"):
+			te = strings.Split(strings.ToLower(v), ",")
+		case strings.EqualFold(k, "Content-Encoding"):
+			ce = strings.Split(strings.ToLower(v), ",")
+		}
+	}
+	var ret []string
+	for _, e := range [][]string{te, ce} {
+		// encodings are listed in the order they were applied
+		for i := len(e) - 1; i >= 0; i-- {
+			switch s := strings.TrimSpace(e[i]); s {
+			case "chunked", "deflate", "gzip":
+				ret = append(ret, s)
+			}
+		}
+	}
+	return ret
+}
+
+var errWebHeader = errors.New("webarchive: invalid record header")
+
+// headerScanner reads the header blocks of the records in a web archive, alongside a webarchive.Reader reading the same file
+type headerScanner struct {
+	br    *bufio.Reader // nil if there are no more headers to read
+	arc   bool
+	block *io.LimitedReader // the content block of the last record read
+}
+
+func newHeaderScanner(r io.Reader, arc bool) *headerScanner {
+	br := bufio.NewReader(r)
+	// compressed web archives have a gzip member for each record
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return &headerScanner{}
+		}
+		br = bufio.NewReader(gz)
+	}
+	h := &headerScanner{br: br, arc: arc}
+	if arc {
+		h.read() // the version block, which webarchive reads when it is opened
+	}
+	return h
+}
+
+// next returns the header of a record returned by the webarchive reader. WARC records are matched by their IDs, so records
+// that webarchive skips are skipped here too. ARC records are read in turn.
+func (h *headerScanner) next(rec webarchive.Record) recordHeader {
+	if h.br == nil {
+		return recordHeader{}
+	}
+	var id string
+	if !h.arc {
+		wr, ok := rec.(*webarchive.WARCReader)
+		if !ok {
+			return recordHeader{} // records merged from segments are returned after the segments have been read
+		}
+		id = wr.ID()
+	}
+	for {
+		hdr, rid, err := h.read()
+		if err != nil {
+			h.br = nil
+			return recordHeader{}
+		}
+		if rid == id {
+			return hdr
+		}
+	}
+}
+
+// read reads the next record's header block and, for ARC records and WARC responses, its HTTP header block.
+// It returns the header and the record's ID (WARC only).
+func (h *headerScanner) read() (recordHeader, string, error) {
+	var hdr recordHeader
+	// skip the remainder of the previous record
+	if h.block != nil {
+		if err := skip(h.block, h.block.N); err != nil {
+			return hdr, "", err
+		}
+	}
+	// records are separated by blank lines
+	line, err := h.br.ReadString('\n')
+	for err == nil && strings.TrimSpace(line) == "" {
+		line, err = h.br.ReadString('\n')
+	}
+	if err != nil {
+		return hdr, "", err
+	}
+	var (
+		id   string
+		sz   int64
+		resp = h.arc
+	)
+	if h.arc {
+		// the length of the record is the last field of an ARC record's header line
+		f := strings.Fields(line)
+		sz, err = strconv.ParseInt(f[len(f)-1], 10, 64)
+	} else {
+		var wh textproto.MIMEHeader
+		if wh, err = textproto.NewReader(h.br).ReadMIMEHeader(); err != nil {
+			return hdr, "", errWebHeader
+		}
+		sz, err = strconv.ParseInt(wh.Get("Content-Length"), 10, 64)
+		id, hdr.digest, resp = wh.Get("WARC-Record-ID"), wh.Get("WARC-Payload-Digest"), wh.Get("WARC-Type") == "response"
+	}
+	if err != nil || sz < 0 {
+		return hdr, "", errWebHeader
+	}
+	h.block = &io.LimitedReader{R: h.br, N: sz}
+	if resp {
+		hdr.status, hdr.http = readHTTP(h.block)
+	}
+	return hdr, id, nil
+}
+
+// readHTTP reads the HTTP header block at the start of a record's content, if it has one
+func readHTTP(r io.Reader) (status string, headers []string) {
+	br := bufio.NewReader(r)
+	if peek, _ := br.Peek(5); string(peek) != "HTTP/" {
+		return
+	}
+	for i := 0; ; i++ {
+		line, err := br.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "":
+			return
+		case i == 0:
+			if _, status, _ = strings.Cut(line, " "); status == "" {
+				status = line
+			}
+		case line[0] == ' ' || line[0] == '\t': // continuation of the previous header
+			if len(headers) > 0 {
+				headers[len(headers)-1] += " " + strings.TrimSpace(line)
+			}
+		default:
+			headers = append(headers, line)
+		}
+		if err != nil { // a truncated record
+			return
+		}
+	}
+}
+
+// CheckDigest verifies a web archive record's payload digest (e.g. "sha1:BASE32DIGEST") against a checksum of its payload
+// calculated with the named hash algorithm. A digest-check field ("match" or "mismatch") is added to the record's fields if the
+// digest can be verified: i.e. if the algorithms are the same and the payload wasn't decoded.
+func CheckDigest(fields [][2]string, alg string, sum []byte) [][2]string {
+	var digest string
+	for _, f := range fields {
+		switch f[0] {
+		case "encoding":
+			return fields
+		case "payload-digest":
+			digest = f[1]
+		}
+	}
+	a, v, ok := strings.Cut(digest, ":")
+	if !ok || !strings.EqualFold(strings.ReplaceAll(a, "-", ""), strings.ReplaceAll(alg, "-", "")) {
+		return fields
+	}
+	for _, dec := range []func(string) ([]byte, error){
+		base32.StdEncoding.DecodeString,
+		base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString,
+		hex.DecodeString,
+		base64.StdEncoding.DecodeString,
+	} {
+		if byt, err := dec(v); err == nil && len(byt) == len(sum) {
+			if bytes.Equal(byt, sum) {
+				return append(fields, [2]string{"digest-check", "match"})
+			}
+			return append(fields, [2]string{"digest-check", "mismatch"})
+		}
+	}
+	return fields
+}
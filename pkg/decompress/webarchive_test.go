@@ -0,0 +1,134 @@
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+func warcRecord(typ, uri, extra, block string) string {
+	return fmt.Sprintf("WARC/1.0\r\nWARC-Type: %s\r\nWARC-Record-ID: <urn:uuid:%s>\r\nWARC-Date: 2008-04-30T20:48:25Z\r\nWARC-Target-URI: %s\r\n%sContent-Length: %d\r\n\r\n%s\r\n\r\n",
+		typ, typ, uri, extra, len(block), block)
+}
+
+func sha1Digest(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
+
+func makeWARC(gz bool) []byte {
+	body := "<html>hello</html>"
+	recs := []string{
+		warcRecord("warcinfo", "", "Content-Type: application/warc-fields\r\n", "software: test\r\n"),
+		warcRecord("response", "http://example.com/", "Content-Type: application/http; msgtype=response\r\nWARC-Payload-Digest: "+sha1Digest(body)+"\r\n",
+			"HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nServer: test\r\n\r\n"+body),
+		warcRecord("revisit", "http://example.com/", "WARC-Payload-Digest: "+sha1Digest(body)+"\r\n", "HTTP/1.1 304 Not Modified\r\n\r\n"),
+		warcRecord("response", "http://example.com/chunked", "WARC-Truncated: length\r\n",
+			"HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n0\r\n\r\n"),
+		warcRecord("resource", "file://hello.txt", "Content-Type: text/plain\r\n", "hello"),
+	}
+	var buf bytes.Buffer
+	for _, r := range recs {
+		if !gz {
+			buf.WriteString(r)
+			continue
+		}
+		w := gzip.NewWriter(&buf)
+		w.Write([]byte(r))
+		w.Close()
+	}
+	return buf.Bytes()
+}
+
+func TestWARC(t *testing.T) {
+	expect := []struct {
+		uri    string
+		mime   string
+		sz     int64
+		data   string
+		fields [][2]string
+	}{
+		{"http://example.com/", "text/html", 18, "<html>hello</html>", [][2]string{
+			{"warc-type", "response"},
+			{"warc-id", "<urn:uuid:response>"},
+			{"http-status", "200 OK"},
+			{"http-headers", "Content-Type: text/html\nServer: test"},
+			{"payload-digest", sha1Digest("<html>hello</html>")},
+		}},
+		{"http://example.com/chunked", "", 15, "hello", [][2]string{
+			{"warc-type", "response"},
+			{"warc-id", "<urn:uuid:response>"},
+			{"http-status", "200 OK"},
+			{"http-headers", "Transfer-Encoding: chunked"},
+			{"encoding", "chunked"},
+			{"truncated", "length"},
+		}},
+		{"file://hello.txt", "text/plain", 5, "hello", [][2]string{
+			{"warc-type", "resource"},
+			{"warc-id", "<urn:uuid:resource>"},
+		}},
+	}
+	bufs := siegreader.New()
+	for _, gz := range []bool{false, true} {
+		buf, err := bufs.Get(bytes.NewReader(makeWARC(gz)))
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		d, err := New(config.WARC, buf, "test.warc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range expect {
+			if err = d.Next(); err != nil {
+				t.Fatalf("%s: %v", e.uri, err)
+			}
+			if d.Path() != Arcpath("test.warc", "20080430204825/"+e.uri) || d.MIME() != e.mime || d.Size() != e.sz {
+				t.Errorf("%s: bad path, MIME or size; got %s, %s, %d", e.uri, d.Path(), d.MIME(), d.Size())
+			}
+			if byt, _ := io.ReadAll(d.Reader()); string(byt) != e.data {
+				t.Errorf("%s: expecting %q, got %q", e.uri, e.data, byt)
+			}
+			fields := d.(Fielder).Fields()
+			if fmt.Sprint(fields) != fmt.Sprint(e.fields) {
+				t.Errorf("%s: expecting fields %v, got %v", e.uri, e.fields, fields)
+			}
+		}
+		if err = d.Next(); err != io.EOF {
+			t.Errorf("expecting EOF, got %v", err)
+		}
+		bufs.Put(buf)
+	}
+}
+
+func TestCheckDigest(t *testing.T) {
+	sum := sha1.Sum([]byte("hello"))
+	for _, v := range []struct {
+		fields [][2]string
+		alg    string
+		expect string
+	}{
+		{[][2]string{{"payload-digest", sha1Digest("hello")}}, "sha1", "match"},
+		{[][2]string{{"payload-digest", fmt.Sprintf("SHA-1:%x", sum)}}, "sha1", "match"},
+		{[][2]string{{"payload-digest", sha1Digest("goodbye")}}, "sha1", "mismatch"},
+		{[][2]string{{"payload-digest", sha1Digest("hello")}}, "md5", ""},
+		{[][2]string{{"encoding", "gzip"}, {"payload-digest", sha1Digest("goodbye")}}, "sha1", ""},
+		{nil, "sha1", ""},
+	} {
+		var got string
+		for _, f := range CheckDigest(v.fields, v.alg, sum[:]) {
+			if f[0] == "digest-check" {
+				got = f[1]
+			}
+		}
+		if got != v.expect {
+			t.Errorf("%v: expecting %q, got %q", v.fields, v.expect, got)
+		}
+	}
+}
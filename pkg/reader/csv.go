@@ -18,11 +18,16 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"strings"
+
+	"github.com/richardlehane/siegfried/internal/checksum"
 )
 
 type sfCSV struct {
 	rdr         *csv.Reader
 	hh          string
+	hashes      int // the number of hash columns
+	extra       []string
 	path        string
 	fields      [][]string
 	identifiers [][2]string
@@ -45,9 +50,15 @@ func newCSV(r io.Reader, path string) (Reader, error) {
 		fieldIdx   = -1
 		fields     = make([][]string, 0, 1)
 	)
-	if rec[fieldStart] != "namespace" {
-		sfc.hh = rec[4]
-		fieldStart++
+	// one or more hash columns
+	var hh []string
+	for ; fieldStart < len(rec) && checksum.GetHash(rec[fieldStart]) >= 0; fieldStart++ {
+		hh = append(hh, rec[fieldStart])
+	}
+	sfc.hh, sfc.hashes = strings.Join(hh, ","), len(hh)
+	// any extra fields precede the first namespace
+	for ; fieldStart < len(rec)-1 && rec[fieldStart] != "namespace"; fieldStart++ {
+		sfc.extra = append(sfc.extra, rec[fieldStart])
 	}
 	if rec[fieldStart] != "namespace" {
 		return nil, fmt.Errorf("bad CSV, expecting field 'namespace' got %s", rec[fieldStart])
@@ -79,6 +90,7 @@ func (sfc *sfCSV) Head() Head {
 		Identifiers: sfc.identifiers,
 		Fields:      sfc.fields,
 		HashHeader:  sfc.hh,
+		Extra:       sfc.extra,
 	}
 }
 
@@ -86,16 +98,19 @@ func (sfc *sfCSV) Next() (File, error) {
 	if sfc.peek == nil || sfc.err != nil {
 		return File{}, sfc.err
 	}
-	fieldStart := 4
-	var hash string
-	if sfc.hh != "" {
-		hash = sfc.peek[fieldStart]
-		fieldStart++
-	}
+	fieldStart := 4 + sfc.hashes
+	// with several hashes, the checksums are concatenated
+	hash := strings.Join(sfc.peek[4:fieldStart], "")
 	file, err := newFile(sfc.peek[0], sfc.peek[1], sfc.peek[2], hash, sfc.peek[3])
 	if err != nil {
 		return file, err
 	}
+	for i, v := range sfc.extra {
+		if val := sfc.peek[fieldStart+i]; val != "" {
+			file.Extra = append(file.Extra, [2]string{v, val})
+		}
+	}
+	fieldStart += len(sfc.extra)
 	fn := sfc.peek[0]
 	for {
 		idStart := fieldStart
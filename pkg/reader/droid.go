@@ -37,6 +37,7 @@ var (
 type droid struct {
 	rdr  *csv.Reader
 	hh   string
+	off  int // the number of HASH columns after the first, which shift the columns that follow
 	path string
 	peek []string
 	err  error
@@ -54,10 +55,16 @@ func newDroid(r io.Reader, path string) (Reader, error) {
 		rdr:  rdr,
 		path: path,
 	}
-	cs := checksum.GetHash(strings.TrimSuffix(rec[12], "_HASH"))
-	if cs >= 0 {
-		dr.hh = cs.String()
+	var hh []string
+	for i := 12; i < len(rec) && strings.HasSuffix(rec[i], "_HASH"); i++ {
+		if i > 12 {
+			dr.off++
+		}
+		if cs := checksum.GetHash(strings.TrimSuffix(rec[i], "_HASH")); cs >= 0 {
+			hh = append(hh, cs.String())
+		}
 	}
+	dr.hh = strings.Join(hh, ",")
 	return dr, dr.nextFile()
 }
 
@@ -101,18 +108,20 @@ func (dr *droid) Next() (File, error) {
 	if dr.peek == nil || dr.err != nil {
 		return File{}, dr.err
 	}
-	file, err := newFile(dr.peek[3], dr.peek[7], dr.peek[10], dr.peek[12], "")
+	// with several hashes, the checksums are concatenated
+	file, err := newFile(dr.peek[3], dr.peek[7], dr.peek[10], strings.Join(dr.peek[12:13+dr.off], ""), "")
 	fn := dr.peek[3]
 	for {
+		ids := dr.peek[13+dr.off:] // FORMAT_COUNT, followed by the PUID, MIME_TYPE, FORMAT_NAME and FORMAT_VERSION of each ID
 		file.IDs = append(file.IDs, newDefaultID(droidFields[0],
-			didVals(dr.peek[14], dr.peek[16], dr.peek[17], dr.peek[15], dr.peek[5], dr.peek[11])))
+			didVals(ids[1], ids[3], ids[4], ids[2], dr.peek[5], dr.peek[11])))
 		// single line multi ids
-		if len(dr.peek) > 18 {
-			num, err := strconv.Atoi(dr.peek[13])
+		if len(ids) > 5 {
+			num, err := strconv.Atoi(ids[0])
 			if err == nil && num > 1 {
 				for i := 1; i < num; i++ {
 					file.IDs = append(file.IDs, newDefaultID(droidFields[0],
-						didVals(dr.peek[14+i*4], dr.peek[16+i*4], dr.peek[17+i*4], dr.peek[15+i*4], dr.peek[5], dr.peek[11])))
+						didVals(ids[1+i*4], ids[3+i*4], ids[4+i*4], ids[2+i*4], dr.peek[5], dr.peek[11])))
 				}
 			}
 		}
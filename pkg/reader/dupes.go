@@ -0,0 +1,146 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reader
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/richardlehane/siegfried/internal/checksum"
+)
+
+// Duplicates groups files by checksum and size. Files without checksums, directories and empty files are ignored.
+type Duplicates struct {
+	hh     string
+	keys   []string // in the order they are first added
+	groups map[string]*dupes
+}
+
+type dupes struct {
+	sz    int64
+	cs    []byte
+	paths []string
+}
+
+// NewDuplicates returns a Duplicates for checksums calculated with the hash algorithm(s) in a hash header (e.g. "md5,sha256")
+func NewDuplicates(hh string) *Duplicates {
+	return &Duplicates{
+		hh:     hh,
+		groups: make(map[string]*dupes),
+	}
+}
+
+// Add adds a file
+func (d *Duplicates) Add(path string, sz int64, cs []byte) {
+	if cs == nil || sz <= 0 {
+		return
+	}
+	key := string(cs) + strconv.FormatInt(sz, 10)
+	g, ok := d.groups[key]
+	if !ok {
+		g = &dupes{sz: sz, cs: append([]byte(nil), cs...)}
+		d.groups[key], d.keys = g, append(d.keys, key)
+	}
+	g.paths = append(g.paths, path)
+}
+
+// Summary returns the number of groups of duplicates, the number of files in those groups, and the bytes that could
+// be saved by keeping one file from each group.
+func (d *Duplicates) Summary() (groups, files int, redundant int64) {
+	for _, key := range d.keys {
+		if g := d.groups[key]; len(g.paths) > 1 {
+			groups++
+			files += len(g.paths)
+			redundant += int64(len(g.paths)-1) * g.sz
+		}
+	}
+	return
+}
+
+// Write writes a CSV report with a row for each duplicate file. Groups of duplicates are numbered in the order that they
+// were first found, and each group's files are sorted by path. A column is written for each hash algorithm.
+func (d *Duplicates) Write(w io.Writer) error {
+	names := strings.Split(d.hh, ",")
+	typs, _ := checksum.GetHashes(d.hh)
+	wrt := csv.NewWriter(w)
+	rec := append(append([]string{"group", "count", "filesize"}, names...), "filename")
+	if err := wrt.Write(rec); err != nil {
+		return err
+	}
+	var group int
+	for _, key := range d.keys {
+		g := d.groups[key]
+		if len(g.paths) < 2 {
+			continue
+		}
+		group++
+		sort.Strings(g.paths)
+		rec[0], rec[1], rec[2] = strconv.Itoa(group), strconv.Itoa(len(g.paths)), strconv.FormatInt(g.sz, 10)
+		if sums := typs.Split(g.cs); len(names) > 1 && sums != nil {
+			for i, sum := range sums {
+				rec[3+i] = hex.EncodeToString(sum)
+			}
+		} else {
+			rec[3] = hex.EncodeToString(g.cs)
+		}
+		for _, p := range g.paths {
+			rec[len(rec)-1] = p
+			if err := wrt.Write(rec); err != nil {
+				return err
+			}
+		}
+	}
+	wrt.Flush()
+	return wrt.Error()
+}
+
+// Dupes writes a CSV report of the duplicate files (files with the same checksum and size) in one or more results files,
+// including the contents of archives. The results files must have checksums calculated with the same hash algorithm(s).
+func Dupes(w io.Writer, paths ...string) error {
+	if len(paths) < 1 {
+		return fmt.Errorf("at least one results file must be provided; got %d", len(paths))
+	}
+	var d *Duplicates
+	for _, v := range paths {
+		f, err := os.Open(v)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		rdr, err := New(f, v)
+		if err != nil {
+			return err
+		}
+		hh := rdr.Head().HashHeader
+		switch {
+		case hh == "":
+			return fmt.Errorf("%s has no checksums", v)
+		case d == nil:
+			d = NewDuplicates(hh)
+		case hh != d.hh:
+			return fmt.Errorf("%s has %s checksums, expecting %s", v, hh, d.hh)
+		}
+		for fi, e := rdr.Next(); e == nil; fi, e = rdr.Next() {
+			d.Add(fi.Path, fi.Size, fi.Hash)
+		}
+	}
+	return d.Write(w)
+}
@@ -64,11 +64,12 @@ func jsonRecord(dec *json.Decoder) (record, error) {
 	for i, v := range vals {
 		m[keys[i]] = v
 	}
+	ks := keys[:len(vals)]
 	keys, vals, err = next(dec)
 	if err != nil {
 		return record{}, err
 	}
-	return record{m, keys, vals}, nil
+	return record{m, ks, keys, vals}, nil
 }
 
 func newJSON(r io.Reader, path string) (Reader, error) {
@@ -82,9 +83,12 @@ func newJSON(r io.Reader, path string) (Reader, error) {
 	if err != nil {
 		return nil, err
 	}
-	next(sfj.dec) // throw away "files": [
+	// throw away "files": [ (JSON lines output has no files key: the header object is followed by an object for each file)
+	if tok, _ := sfj.dec.Token(); tok == "files" {
+		sfj.dec.Token()
+	}
 	sfj.peek, sfj.err = jsonRecord(sfj.dec)
-	sfj.head.HashHeader = getHash(sfj.peek.attributes)
+	sfj.head.HashHeader = getHash(sfj.peek)
 	sfj.head.Fields = getFields(sfj.peek.listFields, sfj.peek.listValues)
 	return sfj, nil
 }
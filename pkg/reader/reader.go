@@ -15,6 +15,7 @@
 package reader
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io"
 	"strconv"
@@ -46,19 +47,23 @@ type Head struct {
 	Identifiers   [][2]string
 	Fields        [][]string
 	HashHeader    string
+	Extra         []string    // names of any extra fields (e.g. WARC record metadata)
+	Annotations   [][2]string // any further information in the header (see writer.Annotator)
 }
 
 type File struct {
-	Path string
-	Size int64
-	Mod  time.Time
-	Hash []byte
-	Err  error
-	IDs  []core.Identification
+	Path  string
+	Size  int64
+	Mod   time.Time
+	Hash  []byte
+	Err   error
+	IDs   []core.Identification
+	Extra [][2]string
 }
 
 type record struct {
 	attributes map[string]string
+	keys       []string // attribute keys, in order
 	listFields []string
 	listValues []string
 }
@@ -85,6 +90,17 @@ func toVersion(str string) ([3]int, error) {
 func getHead(rec record) (Head, error) {
 	head, err := newHeadMap(rec.attributes)
 	head.Identifiers = getIdentifiers(rec.listValues)
+	for _, k := range rec.keys {
+		switch k {
+		case "siegfried", "scandate", "signature", "created", "identifiers", "results":
+		case "extra":
+			if v := rec.attributes[k]; v != "" {
+				head.Extra = strings.Split(v, ",")
+			}
+		default:
+			head.Annotations = append(head.Annotations, [2]string{k, rec.attributes[k]})
+		}
+	}
 	return head, err
 }
 
@@ -124,7 +140,11 @@ func newFile(path, sz, mod, hash, e string) (File, error) {
 		err = fmt.Errorf("bad field, mod: %s, err: %v", mod, err)
 	}
 	if len(hash) > 0 {
-		file.Hash = []byte(hash)
+		var herr error
+		file.Hash, herr = hex.DecodeString(hash)
+		if herr != nil {
+			file.Hash = []byte(hash)
+		}
 	}
 	if e != "" {
 		file.Err = fmt.Errorf("%s", e)
@@ -141,22 +161,26 @@ func newFile(path, sz, mod, hash, e string) (File, error) {
 }
 
 func getFile(rec record) (File, error) {
-	var hh string
-	for k := range rec.attributes {
-		if h := checksum.GetHash(k); h >= 0 {
-			hh = k
-			break
-		}
+	// with several hashes, the checksums are concatenated
+	var hash string
+	for _, k := range hashKeys(rec) {
+		hash += rec.attributes[k]
 	}
 	f, err := newFile(rec.attributes["filename"],
 		rec.attributes["filesize"],
 		rec.attributes["modified"],
-		rec.attributes[hh],
+		hash,
 		rec.attributes["errors"],
 	)
 	if err != nil {
 		return f, err
 	}
+	for _, k := range getExtra(rec) {
+		f.Extra = append(f.Extra, [2]string{k, rec.attributes[k]})
+	}
+	if len(rec.listFields) == 0 {
+		return f, nil
+	}
 	var sidx, eidx int
 	for i, v := range rec.listFields {
 		if v == "ns" {
@@ -171,6 +195,33 @@ func getFile(rec record) (File, error) {
 	return f, nil
 }
 
+// getExtra returns the keys of a record's extra fields: any attributes other than the standard file attributes and hashes
+func getExtra(rec record) []string {
+	var ret []string
+	for _, k := range rec.keys {
+		switch k {
+		case "filename", "filesize", "modified", "errors", "matches":
+			continue
+		}
+		if checksum.GetHash(k) >= 0 {
+			continue
+		}
+		ret = append(ret, k)
+	}
+	return ret
+}
+
+// hashKeys returns the keys of a record's hash attributes, in order
+func hashKeys(rec record) []string {
+	var ret []string
+	for _, k := range rec.keys {
+		if checksum.GetHash(k) >= 0 {
+			ret = append(ret, k)
+		}
+	}
+	return ret
+}
+
 func getIdentifiers(vals []string) [][2]string {
 	ret := make([][2]string, 0, len(vals)/2)
 	for i, v := range vals {
@@ -183,13 +234,13 @@ func getIdentifiers(vals []string) [][2]string {
 	return ret
 }
 
-func getHash(m map[string]string) string {
-	for k := range m {
-		if h := checksum.GetHash(k); h >= 0 {
-			return h.String()
-		}
+// getHash returns the hash header for a record: the names of its hash algorithms, separated by commas
+func getHash(rec record) string {
+	keys := hashKeys(rec)
+	for i, k := range keys {
+		keys[i] = checksum.GetHash(k).String()
 	}
-	return ""
+	return strings.Join(keys, ",")
 }
 
 func getFields(keys, vals []string) [][]string {
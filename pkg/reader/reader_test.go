@@ -2,8 +2,17 @@ package reader
 
 import (
 	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+	"github.com/richardlehane/siegfried/pkg/writer"
 )
 
 const (
@@ -88,3 +97,200 @@ func TestCompare(t *testing.T) {
 		t.Fatalf("expecting a complete match; got %s", string(w.Bytes()))
 	}
 }
+
+func TestExtra(t *testing.T) {
+	fields := []string{"namespace", "id", "format", "version", "mime", "basis", "warning"}
+	ids := []core.Identification{newDefaultID(fields, []string{"pronom", "fmt/96", "HTML", "", "text/html", "byte match", ""})}
+	extra := [][2]string{{"warc-type", "response"}, {"http-headers", "Content-Type: text/html\nServer: 'test'"}}
+	for _, w := range []func(*bytes.Buffer) writer.Writer{
+		func(b *bytes.Buffer) writer.Writer { return writer.CSV(b) },
+		func(b *bytes.Buffer) writer.Writer { return writer.YAML(b) },
+		func(b *bytes.Buffer) writer.Writer { return writer.JSON(b) },
+		func(b *bytes.Buffer) writer.Writer { return writer.JSONLines(b) },
+	} {
+		buf := &bytes.Buffer{}
+		wr := w(buf)
+		wr.Head("", time.Time{}, time.Time{}, [3]int{}, [][2]string{{"pronom", ""}}, [][]string{fields}, "md5", "warc-type", "http-status", "http-headers")
+		wr.File("a.html", 1, "", []byte{1}, nil, ids, extra...)
+		wr.File("b.html", 1, "", []byte{1}, nil, ids)
+		wr.File("c.zip#", 0, "", nil, fmt.Errorf("bad zip"), nil)
+		wr.Tail()
+		rdr, err := New(buf, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rdr.Head().HashHeader != "md5" {
+			t.Errorf("bad hash header: %s", rdr.Head().HashHeader)
+		}
+		f, err := rdr.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(f.Hash, []byte{1}) {
+			t.Errorf("expecting hash 01, got %x", f.Hash)
+		}
+		if fmt.Sprint(f.Extra) != fmt.Sprint(extra) || len(f.IDs) != 1 || f.IDs[0].String() != "fmt/96" {
+			t.Errorf("expecting %v and fmt/96, got %v and %v\n%s", extra, f.Extra, f.IDs, buf)
+		}
+		if f, err = rdr.Next(); err != nil || f.Extra != nil {
+			t.Errorf("expecting no extra fields, got %v (%v)", f.Extra, err)
+		}
+		if f, err = rdr.Next(); err != nil || f.Err == nil || len(f.IDs) != 0 {
+			t.Errorf("expecting an error without matches, got %v, %v (%v)", f.Err, f.IDs, err)
+		}
+	}
+}
+
+// extra fields declared in the header should be replayed even if the first file doesn't have them
+func TestExtraReplay(t *testing.T) {
+	fields := []string{"namespace", "id", "format", "version", "mime", "basis", "warning"}
+	ids := []core.Identification{newDefaultID(fields, []string{"pronom", "fmt/96", "HTML", "", "text/html", "byte match", ""})}
+	for _, w := range []func(*bytes.Buffer) writer.Writer{
+		func(b *bytes.Buffer) writer.Writer { return writer.YAML(b) },
+		func(b *bytes.Buffer) writer.Writer { return writer.JSON(b) },
+		func(b *bytes.Buffer) writer.Writer { return writer.JSONLines(b) },
+	} {
+		buf := &bytes.Buffer{}
+		wr := w(buf)
+		wr.Head("", time.Time{}, time.Time{}, [3]int{}, [][2]string{{"pronom", ""}}, [][]string{fields}, "sha1", "warc-type", "digest-check")
+		wr.File("0first.txt", 1, "", []byte{1}, nil, ids)
+		wr.File("a.warc#index.html", 1, "", []byte{1}, nil, ids, [2]string{"warc-type", "response"}, [2]string{"digest-check", "match"})
+		wr.Tail()
+		rdr, err := New(buf, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		hd := rdr.Head()
+		if fmt.Sprint(hd.Extra) != "[warc-type digest-check]" {
+			t.Fatalf("expecting declared extra fields, got %v\n%s", hd.Extra, buf)
+		}
+		out := &bytes.Buffer{}
+		csv := writer.CSV(out)
+		csv.Head(hd.SignaturePath, hd.Scanned, hd.Created, hd.Version, hd.Identifiers, hd.Fields, hd.HashHeader, hd.Extra...)
+		for f, err := rdr.Next(); err == nil; f, err = rdr.Next() {
+			csv.File(f.Path, f.Size, f.Mod.Format(time.RFC3339), f.Hash, f.Err, f.IDs, f.Extra...)
+		}
+		csv.Tail()
+		lines := strings.Split(out.String(), "\n")
+		if !strings.HasPrefix(lines[0], "filename,filesize,modified,errors,sha1,warc-type,digest-check,namespace") ||
+			!strings.Contains(lines[2], ",response,match,") {
+			t.Errorf("expecting extra fields in replayed results, got\n%s", out)
+		}
+	}
+}
+
+func TestNewFile(t *testing.T) {
+	f, err := newFile("a.html", "1", "2015-05-24T16:59:13+10:00", "0a0b", "")
+	if err != nil || !bytes.Equal(f.Hash, []byte{10, 11}) {
+		t.Errorf("expecting hash 0a0b, got %x (%v)", f.Hash, err)
+	}
+	if f, _ = newFile("a.html", "1", "", "not hex", ""); string(f.Hash) != "not hex" {
+		t.Errorf("expecting the checksum to be kept, got %s", f.Hash)
+	}
+	if _, err = newFile("a.html", "1", "bad date", "0a0b", ""); err == nil {
+		t.Error("expecting a bad mod error")
+	}
+}
+
+func TestHashes(t *testing.T) {
+	fields := []string{"namespace", "id", "format", "version", "mime", "basis", "warning"}
+	ids := []core.Identification{newDefaultID(fields, []string{"pronom", "fmt/96", "HTML", "", "text/html", "byte match", ""})}
+	md5sum, sha256sum := md5.Sum([]byte("hello")), sha256.Sum256([]byte("hello"))
+	cs := append(md5sum[:], sha256sum[:]...)
+	for _, w := range []func(*bytes.Buffer) writer.Writer{
+		func(b *bytes.Buffer) writer.Writer { return writer.CSV(b) },
+		func(b *bytes.Buffer) writer.Writer { return writer.YAML(b) },
+		func(b *bytes.Buffer) writer.Writer { return writer.JSON(b) },
+		func(b *bytes.Buffer) writer.Writer { return writer.JSONLines(b) },
+		func(b *bytes.Buffer) writer.Writer { return writer.Droid(b) },
+	} {
+		buf := &bytes.Buffer{}
+		wr := w(buf)
+		wr.Head("", time.Time{}, time.Time{}, [3]int{}, [][2]string{{"pronom", ""}}, [][]string{fields}, "md5,sha256")
+		wr.File("a.html", 5, "", cs, nil, ids)
+		wr.File("b.html", 5, "", cs, nil, ids)
+		wr.Tail()
+		if !bytes.Contains(buf.Bytes(), []byte(fmt.Sprintf("%x", sha256sum))) {
+			t.Errorf("expecting a sha256 checksum, got\n%s", buf)
+		}
+		rdr, err := New(buf, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rdr.Head().HashHeader != "md5,sha256" {
+			t.Errorf("bad hash header: %s", rdr.Head().HashHeader)
+		}
+		for i := 0; i < 2; i++ {
+			f, err := rdr.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(f.Hash, cs) || len(f.IDs) != 1 || f.IDs[0].String() != "fmt/96" {
+				t.Errorf("expecting hash %x and fmt/96, got %x and %v", cs, f.Hash, f.IDs)
+			}
+		}
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	fields := []string{"namespace", "id", "format", "version", "mime", "basis", "warning"}
+	ids := []core.Identification{newDefaultID(fields, []string{"pronom", "fmt/96", "HTML", "", "text/html", "byte match", ""})}
+	notes := [][2]string{{"bag", "my bag"}, {"bagvalid", "false"}, {"bagerrors", "data/a.html: 'mismatch'"}}
+	for _, w := range []func(*bytes.Buffer) writer.Writer{
+		func(b *bytes.Buffer) writer.Writer { return writer.YAML(b) },
+		func(b *bytes.Buffer) writer.Writer { return writer.JSON(b) },
+		func(b *bytes.Buffer) writer.Writer { return writer.JSONLines(b) },
+	} {
+		buf := &bytes.Buffer{}
+		wr := w(buf)
+		wr.(writer.Annotator).Annotate(notes...)
+		wr.Head("", time.Time{}, time.Time{}, [3]int{}, [][2]string{{"pronom", ""}}, [][]string{fields}, "")
+		wr.File("a.html", 5, "", nil, nil, ids)
+		wr.Tail()
+		rdr, err := New(buf, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hd := rdr.Head(); fmt.Sprint(hd.Annotations) != fmt.Sprint(notes) || len(hd.Identifiers) != 1 {
+			t.Errorf("expecting %v, got %v\n%s", notes, hd.Annotations, buf)
+		}
+		if f, err := rdr.Next(); err != nil || f.Path != "a.html" {
+			t.Errorf("expecting a.html, got %v (%v)", f.Path, err)
+		}
+	}
+}
+
+func TestDupes(t *testing.T) {
+	md5a, md5b := md5.Sum([]byte("same")), md5.Sum([]byte("diff"))
+	path := filepath.Join(t.TempDir(), "results.yaml")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := []string{"namespace", "id", "format", "version", "mime", "basis", "warning"}
+	wr := writer.YAML(f)
+	wr.Head("", time.Time{}, time.Time{}, [3]int{}, [][2]string{{"pronom", ""}}, [][]string{fields}, "md5")
+	wr.File("b.txt", 4, "", md5a[:], nil, nil)
+	wr.File("c.txt", 4, "", md5b[:], nil, nil)
+	wr.File("a.zip", 100, "", []byte{1}, nil, nil)
+	wr.File("a.zip#a.txt", 4, "", md5a[:], nil, nil)
+	wr.File("empty1", 0, "", []byte{2}, nil, nil)
+	wr.File("empty2", 0, "", []byte{2}, nil, nil)
+	wr.Tail()
+	f.Close()
+	buf := &bytes.Buffer{}
+	if err := Dupes(buf, path); err != nil {
+		t.Fatal(err)
+	}
+	expect := fmt.Sprintf("group,count,filesize,md5,filename\n1,2,4,%x,a.zip#a.txt\n1,2,4,%x,b.txt\n", md5a, md5a)
+	if buf.String() != expect {
+		t.Errorf("expecting\n%s, got\n%s", expect, buf)
+	}
+	d := NewDuplicates("md5,sha256")
+	d.Add("a", 10, []byte{1, 2})
+	d.Add("b", 10, []byte{1, 2})
+	d.Add("c", 11, []byte{1, 2})
+	if groups, files, redundant := d.Summary(); groups != 1 || files != 2 || redundant != 10 {
+		t.Errorf("bad summary: %d groups, %d files, %d bytes", groups, files, redundant)
+	}
+}
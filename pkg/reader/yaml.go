@@ -89,8 +89,13 @@ func consumeRecord(buf *bufio.Reader, repl, dbl *strings.Replacer) (record, erro
 		err error
 	)
 	m := make(map[string]string)
+	var keys []string
 	for tok, err = advance(buf, repl, dbl); err == nil && tok.typ == keyval; tok, err = advance(buf, repl, dbl) {
 		m[tok.key] = tok.val
+		keys = append(keys, tok.key)
+	}
+	if _, ok := m["matches"]; ok && (err == io.EOF || (err == nil && tok.typ == divide)) {
+		return record{m, keys, nil, nil}, nil // a record without matches (e.g. a decompression error)
 	}
 	if err != nil || tok.typ != item {
 		if err == nil {
@@ -102,7 +107,7 @@ func consumeRecord(buf *bufio.Reader, repl, dbl *strings.Replacer) (record, erro
 	if err != nil && err != io.EOF {
 		return rec, err
 	}
-	return record{m, ks, vs}, nil
+	return record{m, keys, ks, vs}, nil
 }
 
 func newYAML(r io.Reader, path string) (Reader, error) {
@@ -142,7 +147,7 @@ func (sfy *sfYAML) makeHead(path string) (*sfYAML, error) {
 	rec.attributes["results"] = path
 	sfy.head, err = getHead(rec)
 	sfy.peek, sfy.err = consumeRecord(sfy.buf, sfy.replacer, sfy.dblReplacer)
-	sfy.head.HashHeader = getHash(sfy.peek.attributes)
+	sfy.head.HashHeader = getHash(sfy.peek)
 	sfy.head.Fields = getFields(sfy.peek.listFields, sfy.peek.listValues)
 	return sfy, err
 }
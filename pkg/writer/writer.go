@@ -25,44 +25,98 @@ import (
 	"strings"
 	"time"
 
+	"github.com/richardlehane/siegfried/internal/checksum"
 	"github.com/richardlehane/siegfried/pkg/config"
 	"github.com/richardlehane/siegfried/pkg/core"
 )
 
+// Writer writes results. Extra fields (e.g. WARC record metadata) can be reported for a file as name/value pairs.
+// The names of any extra fields should be declared in Head: writers with fixed columns (CSV) drop undeclared fields,
+// others (YAML and JSON) list the declared names in their header and write a file's extra fields if they have values.
+//
+// The hash header (hh) names the hash algorithm used for checksums. It can list several algorithms, separated by commas
+// (e.g. "md5,sha256"), in which case a file's checksum is the checksums for each algorithm concatenated in the same order.
+// Each algorithm is written in its own column or field.
 type Writer interface {
-	Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) // 	path := filepath.Base(path)
-	File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification)               // if a directory give a negative sz
+	Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string, extra ...string) // 	path := filepath.Base(path)
+	File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification, extra ...[2]string)            // if a directory give a negative sz
 	Tail()
 }
 
+// Annotator is implemented by writers that can report information about a scan in their header (e.g. the validity of a
+// BagIt bag) as name/value pairs. Annotate should be called before Head. YAML and JSON writers are annotators; CSV and
+// DROID output don't have a header for annotations.
+type Annotator interface {
+	Annotate(pairs ...[2]string)
+}
+
+// Flusher is implemented by writers that buffer their output. Flush writes any buffered results, so that results can
+// be streamed (e.g. when watching a directory) before Tail is called.
+type Flusher interface {
+	Flush()
+}
+
 func Null() Writer {
 	return null{}
 }
 
 type null struct{}
 
-func (n null) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+func (n null) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string, extra ...string) {
 }
-func (n null) File(name string, sz int64, mod string, cs []byte, err error, ids []core.Identification) {
+func (n null) File(name string, sz int64, mod string, cs []byte, err error, ids []core.Identification, extra ...[2]string) {
 }
 func (n null) Tail() {}
 
-type csvWriter struct {
-	recs  [][]string
+// hashes are the hash algorithms listed in a hash header
+type hashes struct {
 	names []string
-	w     *csv.Writer
+	typs  checksum.HashTyps
+}
+
+func newHashes(hh string) hashes {
+	if hh == "" {
+		return hashes{}
+	}
+	typs, _ := checksum.GetHashes(hh)
+	return hashes{strings.Split(hh, ","), typs}
+}
+
+// encode returns a hex encoded checksum for each hash algorithm. The strings are empty if the checksum is nil.
+func (h hashes) encode(cs []byte) []string {
+	ret := make([]string, len(h.names))
+	if cs == nil {
+		return ret
+	}
+	if len(h.names) == 1 {
+		ret[0] = hex.EncodeToString(cs)
+		return ret
+	}
+	if len(h.typs) != len(h.names) {
+		return ret
+	}
+	for i, v := range h.typs.Split(cs) {
+		ret[i] = hex.EncodeToString(v)
+	}
+	return ret
+}
+
+type csvWriter struct {
+	recs   [][]string
+	names  []string
+	hashes hashes
+	extra  []string
+	w      *csv.Writer
 }
 
 func CSV(w io.Writer) Writer {
 	return &csvWriter{w: csv.NewWriter(w)}
 }
 
-func (c *csvWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+func (c *csvWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string, extra ...string) {
 	c.names = make([]string, len(fields))
-	l := 4
-	if hh != "" {
-		l++
-	}
+	c.hashes, c.extra = newHashes(hh), extra
+	l := 4 + len(c.hashes.names) + len(extra)
 	for i, f := range fields {
 		l += len(f)
 		c.names[i] = f[0]
@@ -71,10 +125,8 @@ func (c *csvWriter) Head(path string, scanned, created time.Time, version [3]int
 	c.recs[0] = make([]string, l)
 	c.recs[0][0], c.recs[0][1], c.recs[0][2], c.recs[0][3] = "filename", "filesize", "modified", "errors"
 	idx := 4
-	if hh != "" {
-		c.recs[0][4] = hh
-		idx++
-	}
+	idx += copy(c.recs[0][idx:], c.hashes.names)
+	idx += copy(c.recs[0][idx:], extra)
 	for _, f := range fields {
 		copy(c.recs[0][idx:], f)
 		idx += len(f)
@@ -82,22 +134,29 @@ func (c *csvWriter) Head(path string, scanned, created time.Time, version [3]int
 	c.w.Write(c.recs[0])
 }
 
-func (c *csvWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+func (c *csvWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification, extra ...[2]string) {
 	var errStr string
 	if err != nil {
 		errStr = err.Error()
 	}
 	c.recs[0][0], c.recs[0][1], c.recs[0][2], c.recs[0][3] = name, strconv.FormatInt(sz, 10), mod, errStr
 	idx := 4
-	if checksum != nil {
-		c.recs[0][4] = hex.EncodeToString(checksum)
-		idx++
+	if len(ids) == 0 {
+		checksum = nil
+	}
+	idx += copy(c.recs[0][idx:], c.hashes.encode(checksum))
+	for i, e := range c.extra {
+		c.recs[0][idx+i] = ""
+		for _, v := range extra {
+			if v[0] == e {
+				c.recs[0][idx+i] = v[1]
+				break
+			}
+		}
 	}
+	idx += len(c.extra)
 	if len(ids) == 0 {
 		empty := make([]string, len(c.recs[0])-idx)
-		if checksum != nil {
-			c.recs[0][4] = ""
-		}
 		copy(c.recs[0][idx:], empty)
 		c.w.Write(c.recs[0])
 		return
@@ -130,13 +189,18 @@ func (c *csvWriter) File(name string, sz int64, mod string, checksum []byte, err
 
 func (c *csvWriter) Tail() { c.w.Flush() }
 
+func (c *csvWriter) Flush() { c.w.Flush() }
+
 type yamlWriter struct {
 	replacer    *strings.Replacer
 	dblReplacer *strings.Replacer
 	w           *bufio.Writer
-	hh          string
+	hashes      hashes
 	hstrs       []string
 	vals        [][]interface{}
+	notes       [][2]string
+	pad         int    // width of the keys in file records
+	rec         string // format for the standard keys in file records
 }
 
 const nonPrintables = "\x00\x07\x08\x0A\x0B\x0C\x0D\x1B"
@@ -179,26 +243,49 @@ func header(fields []string) string {
 	return "  - " + strings.Join(headings, " : %v\n    ") + " : %v\n"
 }
 
-func (y *yamlWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
-	y.hh = hh
+func (y *yamlWriter) Annotate(pairs ...[2]string) { y.notes = append(y.notes, pairs...) }
+
+func (y *yamlWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string, extra ...string) {
+	y.hashes = newHashes(hh)
 	y.hstrs = make([]string, len(fields))
 	y.vals = make([][]interface{}, len(fields))
 	for i, f := range fields {
 		y.hstrs[i] = header(f)
 		y.vals[i] = make([]interface{}, len(f))
 	}
+	// align file record keys with the longest hash or extra field name
+	y.pad = len("filename")
+	for _, k := range y.hashes.names {
+		if len(k) > y.pad {
+			y.pad = len(k)
+		}
+	}
+	for _, k := range extra {
+		if len(k) > y.pad {
+			y.pad = len(k)
+		}
+	}
+	y.rec = fmt.Sprintf("---\n%-*s : %%s\n%-*s : %%d\n%-*s : %%s\n%-*s : %%s\n%%s%-*s :\n",
+		y.pad, "filename", y.pad, "filesize", y.pad, "modified", y.pad, "errors", y.pad, "matches")
 	fmt.Fprintf(y.w,
-		"---\nsiegfried   : %d.%d.%d\nscandate    : %v\nsignature   : %s\ncreated     : %v\nidentifiers : \n",
+		"---\nsiegfried   : %d.%d.%d\nscandate    : %v\nsignature   : %s\ncreated     : %v\n",
 		version[0], version[1], version[2],
 		scanned.Format(time.RFC3339),
 		y.replacer.Replace(path),
 		created.Format(time.RFC3339))
+	if len(extra) > 0 {
+		fmt.Fprintf(y.w, "extra       : '%s'\n", strings.Join(extra, ","))
+	}
+	for _, n := range y.notes {
+		fmt.Fprintf(y.w, "%-12s: '%s'\n", n[0], y.replacer.Replace(n[1]))
+	}
+	fmt.Fprint(y.w, "identifiers : \n")
 	for _, id := range ids {
 		fmt.Fprintf(y.w, "  - name    : '%v'\n    details : '%v'\n", id[0], id[1])
 	}
 }
 
-func (y *yamlWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+func (y *yamlWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification, extra ...[2]string) {
 	var (
 		errStr   string
 		h        string
@@ -210,14 +297,17 @@ func (y *yamlWriter) File(name string, sz int64, mod string, checksum []byte, er
 		errStr = "'" + y.replacer.Replace(err.Error()) + "'"
 	}
 	if checksum != nil {
-		h = fmt.Sprintf("%-8s : %s\n", y.hh, hex.EncodeToString(checksum))
+		for i, v := range y.hashes.encode(checksum) {
+			h += fmt.Sprintf("%-*s : %s\n", y.pad, y.hashes.names[i], v)
+		}
 	}
-	if strings.ContainsAny(name, nonPrintables) {
-		fname = "\"" + y.dblReplacer.Replace(name) + "\""
-	} else {
-		fname = "'" + y.replacer.Replace(name) + "'"
+	for _, v := range extra {
+		if v[1] != "" {
+			h += fmt.Sprintf("%-*s : %s\n", y.pad, v[0], y.quote(v[1]))
+		}
 	}
-	fmt.Fprintf(y.w, "---\nfilename : %s\nfilesize : %d\nmodified : %s\nerrors   : %s\n%smatches  :\n", fname, sz, mod, errStr, h)
+	fname = y.quote(name)
+	fmt.Fprintf(y.w, y.rec, fname, sz, mod, errStr, h)
 	for _, id := range ids {
 		values := id.Values()
 		if values[0] != thisName {
@@ -235,14 +325,26 @@ func (y *yamlWriter) File(name string, sz int64, mod string, checksum []byte, er
 	}
 }
 
+// quote single quotes a string, or double quotes it if it contains non-printable characters
+func (y *yamlWriter) quote(s string) string {
+	if strings.ContainsAny(s, nonPrintables) {
+		return "\"" + y.dblReplacer.Replace(s) + "\""
+	}
+	return "'" + y.replacer.Replace(s) + "'"
+}
+
 func (y *yamlWriter) Tail() { y.w.Flush() }
 
+func (y *yamlWriter) Flush() { y.w.Flush() }
+
 type jsonWriter struct {
 	subs     bool
+	lines    bool // JSON lines (see JSONLines)
 	replacer *strings.Replacer
 	w        *bufio.Writer
-	hh       string
+	hashes   hashes
 	hstrs    []func([]string) string
+	notes    [][2]string
 }
 
 func JSON(w io.Writer) Writer {
@@ -281,46 +383,68 @@ func JSON(w io.Writer) Writer {
 	}
 }
 
+// JSONLines writes results as JSON lines: the header is written as an object on the first line, followed by an object
+// for each file on its own line. Unlike JSON output, results are valid as they are written, so can be streamed.
+func JSONLines(w io.Writer) Writer {
+	j := JSON(w).(*jsonWriter)
+	j.lines = true
+	return j
+}
+
 func jsonizer(fields []string) func([]string) string {
+	keys := make([]string, len(fields)) // don't modify the caller's fields
 	for i, v := range fields {
 		if v == "namespace" {
-			fields[i] = "\"ns\":\""
+			keys[i] = "\"ns\":\""
 			continue
 		}
-		fields[i] = "\"" + v + "\":\""
+		keys[i] = "\"" + v + "\":\""
 	}
 	vals := make([]string, len(fields))
 	return func(values []string) string {
 		for i, v := range values {
-			vals[i] = fields[i] + v
+			vals[i] = keys[i] + v
 		}
 		return "{" + strings.Join(vals, "\",") + "\"}"
 	}
 }
 
-func (j *jsonWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
-	j.hh = hh
+func (j *jsonWriter) Annotate(pairs ...[2]string) { j.notes = append(j.notes, pairs...) }
+
+func (j *jsonWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string, extra ...string) {
+	j.hashes = newHashes(hh)
 	j.hstrs = make([]func([]string) string, len(fields))
 	for i, f := range fields {
 		j.hstrs[i] = jsonizer(f)
 	}
 	fmt.Fprintf(j.w,
-		"{\"siegfried\":\"%d.%d.%d\",\"scandate\":\"%v\",\"signature\":\"%s\",\"created\":\"%v\",\"identifiers\":[",
+		"{\"siegfried\":\"%d.%d.%d\",\"scandate\":\"%v\",\"signature\":\"%s\",\"created\":\"%v\",",
 		version[0], version[1], version[2],
 		scanned.Format(time.RFC3339),
 		path,
 		created.Format(time.RFC3339))
+	if len(extra) > 0 {
+		fmt.Fprintf(j.w, "\"extra\":\"%s\",", strings.Join(extra, ","))
+	}
+	for _, n := range j.notes {
+		fmt.Fprintf(j.w, "\"%s\":\"%s\",", n[0], j.replacer.Replace(n[1]))
+	}
+	j.w.WriteString("\"identifiers\":[")
 	for i, id := range ids {
 		if i > 0 {
 			j.w.WriteString(",")
 		}
 		fmt.Fprintf(j.w, "{\"name\":\"%s\",\"details\":\"%s\"}", id[0], id[1])
 	}
+	if j.lines {
+		j.w.WriteString("]}\n")
+		return
+	}
 	j.w.WriteString("],\"files\":[")
 }
 
-func (j *jsonWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
-	if j.subs {
+func (j *jsonWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification, extra ...[2]string) {
+	if j.subs && !j.lines {
 		j.w.WriteString(",")
 	}
 	var (
@@ -333,7 +457,14 @@ func (j *jsonWriter) File(name string, sz int64, mod string, checksum []byte, er
 		errStr = err.Error()
 	}
 	if checksum != nil {
-		h = fmt.Sprintf("\"%s\":\"%s\",", j.hh, hex.EncodeToString(checksum))
+		for i, v := range j.hashes.encode(checksum) {
+			h += fmt.Sprintf("\"%s\":\"%s\",", j.hashes.names[i], v)
+		}
+	}
+	for _, v := range extra {
+		if v[1] != "" {
+			h += fmt.Sprintf("\"%s\":\"%s\",", v[0], j.replacer.Replace(v[1]))
+		}
 	}
 	fmt.Fprintf(j.w, "{\"filename\":\"%s\",\"filesize\": %d,\"modified\":\"%s\",\"errors\": \"%s\",%s\"matches\": [", j.replacer.Replace(name), sz, mod, errStr, h)
 	for i, id := range ids {
@@ -348,18 +479,28 @@ func (j *jsonWriter) File(name string, sz int64, mod string, checksum []byte, er
 		j.w.WriteString(j.hstrs[idx](values))
 	}
 	j.w.WriteString("]}")
+	if j.lines {
+		j.w.WriteString("\n")
+	}
 	j.subs = true
 }
 
 func (j *jsonWriter) Tail() {
-	j.w.WriteString("]}\n")
+	if !j.lines {
+		j.w.WriteString("]}\n")
+	}
 	j.w.Flush()
 }
 
+func (j *jsonWriter) Flush() { j.w.Flush() }
+
 type droidWriter struct {
 	id      int
 	parents map[string]parent
 	rec     []string
+	hashes  hashes
+	sums    []string // the HASH columns, which are written in place of rec[12]
+	out     []string
 	w       *csv.Writer
 }
 
@@ -378,18 +519,31 @@ func Droid(w io.Writer) Writer {
 }
 
 // "identifier", "id", "format name", "format version", "mimetype", "basis", "warning"
-func (d *droidWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+func (d *droidWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string, extra ...string) {
 	if hh == "" {
 		hh = "no"
 	}
-	d.w.Write([]string{
+	d.hashes = newHashes(hh)
+	copy(d.rec, []string{
 		"ID", "PARENT_ID", "URI", "FILE_PATH", "NAME",
 		"METHOD", "STATUS", "SIZE", "TYPE", "EXT",
-		"LAST_MODIFIED", "EXTENSION_MISMATCH", strings.ToUpper(hh) + "_HASH", "FORMAT_COUNT",
+		"LAST_MODIFIED", "EXTENSION_MISMATCH", "", "FORMAT_COUNT",
 		"PUID", "MIME_TYPE", "FORMAT_NAME", "FORMAT_VERSION"})
+	d.sums = make([]string, len(d.hashes.names))
+	for i, v := range d.hashes.names {
+		d.sums[i] = strings.ToUpper(v) + "_HASH"
+	}
+	d.write()
+}
+
+// write writes a record with a HASH column for each hash algorithm
+func (d *droidWriter) write() {
+	d.out = append(append(append(d.out[:0], d.rec[:12]...), d.sums...), d.rec[13:]...)
+	d.w.Write(d.out)
 }
 
-func (d *droidWriter) File(p string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+// DROID output has fixed columns, so extra fields are not written
+func (d *droidWriter) File(p string, sz int64, mod string, checksum []byte, err error, ids []core.Identification, extra ...[2]string) {
 	d.id++
 	d.rec[0], d.rec[6], d.rec[10] = strconv.Itoa(d.id), "Done", mod
 	if err != nil {
@@ -398,7 +552,8 @@ func (d *droidWriter) File(p string, sz int64, mod string, checksum []byte, err
 	d.rec[1], d.rec[2], d.rec[3], d.rec[4], d.rec[9] = d.processPath(p)
 	// if folder (has sz -1) or error
 	if sz < 0 || ids == nil {
-		d.rec[5], d.rec[7], d.rec[12], d.rec[13], d.rec[14], d.rec[15], d.rec[16], d.rec[17] = "", "", "", "", "", "", "", ""
+		d.rec[5], d.rec[7], d.rec[13], d.rec[14], d.rec[15], d.rec[16], d.rec[17] = "", "", "", "", "", "", ""
+		d.sums = d.hashes.encode(nil)
 		if sz < 0 {
 			d.rec[8], d.rec[9], d.rec[11] = "Folder", "", "false"
 			d.parents[d.rec[3]] = parent{d.id, d.rec[2], ""}
@@ -408,22 +563,18 @@ func (d *droidWriter) File(p string, sz int64, mod string, checksum []byte, err
 			d.rec[8], d.rec[11] = "", ""
 			d.rec[3] = clearArchivePath(d.rec[2], d.rec[3])
 		}
-		d.w.Write(d.rec)
+		d.write()
 		return
 	}
 	// size
 	d.rec[7] = strconv.FormatInt(sz, 10)
-	if checksum == nil {
-		d.rec[12] = ""
-	} else {
-		d.rec[12] = hex.EncodeToString(checksum)
-	}
+	d.sums = d.hashes.encode(checksum)
 	// leave early for unknowns
 	if len(ids) < 1 || !ids[0].Known() {
 		d.rec[5], d.rec[8], d.rec[11], d.rec[13] = "", "File", "FALSE", "0"
 		d.rec[14], d.rec[15], d.rec[16], d.rec[17] = "", "", "", ""
 		d.rec[3] = clearArchivePath(d.rec[2], d.rec[3])
-		d.w.Write(d.rec)
+		d.write()
 		return
 	}
 	d.rec[13] = strconv.Itoa(len(ids))
@@ -438,12 +589,14 @@ func (d *droidWriter) File(p string, sz int64, mod string, checksum []byte, err
 		d.rec[5], d.rec[11] = getMethod(fields[len(fields)-2]), mismatch(fields[len(fields)-1])
 		d.rec[14], d.rec[15], d.rec[16], d.rec[17] = fields[1], fields[4], fields[2], fields[3]
 		d.rec[3] = clearArchivePath(d.rec[2], d.rec[3])
-		d.w.Write(d.rec)
+		d.write()
 	}
 }
 
 func (d *droidWriter) Tail() { d.w.Flush() }
 
+func (d *droidWriter) Flush() { d.w.Flush() }
+
 func (d *droidWriter) processPath(p string) (parent, uri, path, name, ext string) {
 	path, _ = filepath.Abs(p)
 	path = strings.TrimSuffix(path, string(filepath.Separator))
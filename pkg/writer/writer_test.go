@@ -104,6 +104,15 @@ func TestNonControlCharacters(t *testing.T) {
 	}
 }
 
+func TestJSONFields(t *testing.T) {
+	fields := makeFields()
+	js := JSON(ioutil.Discard)
+	js.Head("", time.Time{}, time.Time{}, [3]int{}, [][2]string{{"pronom", ""}}, [][]string{fields}, "")
+	if fmt.Sprint(fields) != fmt.Sprint(makeFields()) {
+		t.Errorf("expecting the fields to be unchanged, got %v", fields)
+	}
+}
+
 func TestYAMLHeader(t *testing.T) {
 	expect := "  - ns      : %v\n    id      : %v\n    format  : %v\n    version : %v\n    mime    : %v\n    basis   : %v\n    warning : %v\n"
 	ret := header(makeFields())
@@ -204,3 +213,17 @@ func ExampleJSON() {
 	// Output:
 	// {"filename":"example.doc","filesize": 1,"modified":"2015-05-24T16:59:13+10:00","errors": "mscfb: bad OLE","matches": [{"ns":"pronom","id":"fmt/43","format":"JPEG File Interchange Format","version":"1.01","mime":"image/jpeg","basis":"extension match jpg; byte match at [[[0 14]] [[75201 2]]]","warning":""}]}]}
 }
+
+func ExampleJSONLines() {
+	js := JSONLines(ioutil.Discard)
+	js.(*jsonWriter).w = bufio.NewWriter(os.Stdout)
+	js.Head("", time.Time{}, time.Time{}, [3]int{}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	js.File("example.doc", 1, "2015-05-24T16:59:13+10:00", nil, testErr{}, []core.Identification{testID{}})
+	js.(Flusher).Flush()
+	js.File("example2.doc", 1, "2015-05-24T16:59:13+10:00", nil, nil, nil)
+	js.Tail()
+	// Output:
+	// {"siegfried":"0.0.0","scandate":"0001-01-01T00:00:00Z","signature":"","created":"0001-01-01T00:00:00Z","identifiers":[{"name":"pronom","details":""}]}
+	// {"filename":"example.doc","filesize": 1,"modified":"2015-05-24T16:59:13+10:00","errors": "mscfb: bad OLE","matches": [{"ns":"pronom","id":"fmt/43","format":"JPEG File Interchange Format","version":"1.01","mime":"image/jpeg","basis":"extension match jpg; byte match at [[[0 14]] [[75201 2]]]","warning":""}]}
+	// {"filename":"example2.doc","filesize": 1,"modified":"2015-05-24T16:59:13+10:00","errors": "","matches": []}
+}
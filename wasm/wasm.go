@@ -59,6 +59,7 @@ func identifyRdr(
 	r io.Reader,
 	w writer.Writer,
 	path string,
+	parent string, // path of the archive this file is within, if any
 	mime string,
 	sz int64,
 	mod time.Time,
@@ -73,6 +74,10 @@ func identifyRdr(
 		w.File(path, sz, mod.Format(time.RFC3339), nil, err, ids)
 		return
 	}
+	// some decompressed streams (e.g. bzip2) don't report their size in advance
+	if sz < 0 {
+		sz = b.SizeNow()
+	}
 	// calculate checksum
 	var cs []byte
 	if h != nil {
@@ -97,7 +102,12 @@ func identifyRdr(
 		w.File(path, sz, mod.Format(time.RFC3339), cs, err, ids)
 		return
 	}
-	d, err := decompress.New(arc, b, path)
+	var d decompress.Decompressor
+	if parent == "" {
+		d, err = decompress.New(arc, b, path)
+	} else {
+		d, err = decompress.NewEntry(arc, b, path, parent)
+	}
 	if err != nil {
 		w.File(path, sz, mod.Format(time.RFC3339), cs, fmt.Errorf("failed to decompress, got: %v", err), ids)
 		return
@@ -106,7 +116,8 @@ func identifyRdr(
 	w.File(path, sz, mod.Format(time.RFC3339), cs, err, ids)
 	// decompress and recurse
 	for err = d.Next(); ; err = d.Next() {
-		if err != nil {
+		_, entryErr := err.(decompress.EntryError)
+		if err != nil && !entryErr {
 			if err == io.EOF {
 				return
 			}
@@ -118,7 +129,11 @@ func identifyRdr(
 				w.File(v, -1, "", nil, nil, nil)
 			}
 		}
-		identifyRdr(s, d.Reader(), w, d.Path(), d.MIME(), d.Size(), d.Mod(), h, z, do)
+		if entryErr {
+			w.File(d.Path(), d.Size(), d.Mod().Format(time.RFC3339), nil, err, nil)
+			continue
+		}
+		identifyRdr(s, d.Reader(), w, d.Path(), path, d.MIME(), d.Size(), d.Mod(), h, z, do)
 	}
 }
 
@@ -145,7 +160,7 @@ func identifyFile(
 	modUnix := int64(val.Get("lastModified").Int())
 	mod = time.UnixMilli(modUnix)
 	r.reset(val)
-	identifyRdr(s, r, w, name, "", r.Size(), mod, h, z, do)
+	identifyRdr(s, r, w, name, "", "", r.Size(), mod, h, z, do)
 	return nil
 }
 
@@ -185,7 +200,7 @@ func identifyFiles(
 // identify(FileSystemHandle, ...OPTS)
 // OPTS: json (default), csv, yaml, droid,
 //
-//	     md5, sha1, sha256, sha512, crc,
+//	     md5, sha1, sha256, sha512, sha3-256, blake2b-512, blake3, xxh64, crc,
 //		 z
 func sfWrapper(sf *siegfried.Siegfried) js.Func {
 	return js.FuncOf(func(this js.Value, args []js.Value) any {